@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+const (
+	defaultDragCoefficient = 0.3  // typical for an artillery shell
+	defaultProjectileMass  = 43.5 // kilograms
+	defaultCaliber         = 155  // millimeters
+	flightTimeStep         = 0.05 // seconds
+	maxFlightTime          = 1000 // seconds
+	angleTolerance         = 1e-6 // degrees
+	terrainSegments        = 10
+)
+
+// ballistics computes where and when a projectile lands. The zero value is the vacuum model in still air
+// on level ground.
+type ballistics struct {
+	drag    *dragModel // nil = no air resistance
+	wind    float64    // meters/sec, + = tail wind, - = head wind
+	terrain terrain    // the projectile lands where it comes down onto the terrain
+}
+
+// dragModel integrates the flight numerically with air resistance in a standard atmosphere.
+type dragModel struct {
+	coefficient float64 // drag coefficient
+	mass        float64 // kilograms
+	caliber     float64 // meters
+}
+
+func newDragModel(coefficient, mass, caliberMillimeters float64) (*dragModel, error) {
+	if coefficient <= 0.0 || mass <= 0.0 || caliberMillimeters <= 0.0 {
+		return nil, fmt.Errorf("invalid drag model: coefficient, mass and caliber must all be positive")
+	}
+	return &dragModel{coefficient: coefficient, mass: mass, caliber: caliberMillimeters / 1000.0}, nil
+}
+
+// flight returns the range and flight time of a shot at angle with muzzle velocity v.
+func (b ballistics) flight(angle, v float64) (x, t float64) {
+	if b.drag == nil && b.terrain.level() {
+		// Without air resistance the projectile simply drifts along with the wind.
+		x, t = xRange(angle, v)
+		if h := b.terrain.elevation; h != 0.0 {
+			t = xTime(angle, v, h)
+			x = math.Cos((2.0*math.Pi)*(angle/360.0)) * t * v
+		}
+		return x + b.wind*t, t
+	}
+	return b.integrate(angle, v)
+}
+
+// closedForm reports whether the vacuum formulas in xRange() and xAngle() apply as they are.
+func (b ballistics) closedForm() bool {
+	return b.drag == nil && b.wind == 0.0 && b.terrain.level() && b.terrain.elevation == 0.0
+}
+
+// angleFor returns the (low) shot angle that travels x, or NaN if x is out of range.
+func (b ballistics) angleFor(x, v float64) float64 {
+	if b.closedForm() {
+		return xAngle(x, v)
+	}
+	bestAngle := b.maxRangeAngle(v)
+	if maxX, _ := b.flight(bestAngle, v); x > maxX || x < 0.0 {
+		return math.NaN()
+	}
+	// The range only grows with the angle up to bestAngle.
+	low, high := 0.0, bestAngle
+	for high-low > angleTolerance {
+		mid := (low + high) / 2.0
+		if midX, _ := b.flight(mid, v); midX < x {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2.0
+}
+
+// highAngleFor returns the high shot angle (up to maxHighShotAngle) that travels x, or NaN if x is
+// out of range.
+func (b ballistics) highAngleFor(x, v float64) float64 {
+	if b.closedForm() {
+		return 90.0 - xAngle(x, v)
+	}
+	bestAngle := b.maxRangeAngle(v)
+	if maxX, _ := b.flight(bestAngle, v); x > maxX {
+		return math.NaN()
+	}
+	// The range only shrinks with the angle past bestAngle.
+	low, high := bestAngle, maxHighShotAngle
+	for high-low > angleTolerance {
+		mid := (low + high) / 2.0
+		if midX, _ := b.flight(mid, v); midX > x {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2.0
+}
+
+// maxRangeAngle returns the shot angle (up to maxShotAngle) that travels the furthest.
+func (b ballistics) maxRangeAngle(v float64) float64 {
+	if b.closedForm() {
+		return maxShotAngle
+	}
+	// Golden section search, the range has a single peak over the angle.
+	ratio := (math.Sqrt(5.0) - 1.0) / 2.0
+	low, high := minShotAngle, maxShotAngle
+	for high-low > angleTolerance {
+		a1 := high - ratio*(high-low)
+		a2 := low + ratio*(high-low)
+		x1, _ := b.flight(a1, v)
+		x2, _ := b.flight(a2, v)
+		if x1 < x2 {
+			low = a1
+		} else {
+			high = a2
+		}
+	}
+	return (low + high) / 2.0
+}
+
+// maxRange returns the furthest distance that a shot can travel.
+func (b ballistics) maxRange(v float64) float64 {
+	x, _ := b.flight(b.maxRangeAngle(v), v)
+	return x
+}
+
+type projectileState struct {
+	x, y, vx, vy float64
+}
+
+// acceleration returns the gravity plus the drag from the air moving past the projectile.
+func (d *dragModel) acceleration(s projectileState, wind float64) (ax, ay float64) {
+	area := math.Pi * d.caliber * d.caliber / 4.0
+	k := 0.5 * airDensity(s.y) * d.coefficient * area / d.mass
+	airspeed := math.Hypot(s.vx-wind, s.vy)
+	return -k * airspeed * (s.vx - wind), -gravityAmps2 - k*airspeed*s.vy
+}
+
+// step advances the state by dt, exactly in a vacuum or with the Runge-Kutta method with drag.
+func (b ballistics) step(s projectileState, dt float64) projectileState {
+	if b.drag == nil {
+		return projectileState{
+			x:  s.x + s.vx*dt,
+			y:  s.y + s.vy*dt - gravityAmps2*dt*dt/2.0,
+			vx: s.vx,
+			vy: s.vy - gravityAmps2*dt,
+		}
+	}
+	return b.drag.step(s, dt, b.wind)
+}
+
+// integrate follows the flight step by step until the projectile comes down onto the terrain.
+func (b ballistics) integrate(angle, v float64) (x, t float64) {
+	radians := (2.0 * math.Pi) * (angle / 360.0)
+	s := projectileState{vx: math.Cos(radians) * v, vy: math.Sin(radians) * v}
+	if b.drag == nil {
+		s.vx += b.wind
+	}
+	for t < maxFlightTime {
+		next := b.step(s, flightTimeStep)
+		if above := next.y - b.terrain.height(next.x); above <= 0.0 && next.vy < 0.0 {
+			// Interpolate the landing between the last two steps.
+			fraction := 0.0
+			if last := s.y - b.terrain.height(s.x); last > 0.0 {
+				fraction = last / (last - above)
+			}
+			return s.x + fraction*(next.x-s.x), t + fraction*flightTimeStep
+		}
+		s = next
+		t += flightTimeStep
+	}
+	return s.x, t
+}
+
+// step advances the state by dt with the classic Runge-Kutta method.
+func (d *dragModel) step(s projectileState, dt, wind float64) projectileState {
+	derivative := func(s projectileState) projectileState {
+		ax, ay := d.acceleration(s, wind)
+		return projectileState{s.vx, s.vy, ax, ay}
+	}
+	add := func(s, ds projectileState, h float64) projectileState {
+		return projectileState{s.x + ds.x*h, s.y + ds.y*h, s.vx + ds.vx*h, s.vy + ds.vy*h}
+	}
+	k1 := derivative(s)
+	k2 := derivative(add(s, k1, dt/2.0))
+	k3 := derivative(add(s, k2, dt/2.0))
+	k4 := derivative(add(s, k3, dt))
+	return projectileState{
+		x:  s.x + dt/6.0*(k1.x+2.0*k2.x+2.0*k3.x+k4.x),
+		y:  s.y + dt/6.0*(k1.y+2.0*k2.y+2.0*k3.y+k4.y),
+		vx: s.vx + dt/6.0*(k1.vx+2.0*k2.vx+2.0*k3.vx+k4.vx),
+		vy: s.vy + dt/6.0*(k1.vy+2.0*k2.vy+2.0*k3.vy+k4.vy),
+	}
+}
+
+// airDensity returns the density (kg/m^3) of the International Standard Atmosphere at altitude.
+func airDensity(altitude float64) float64 {
+	const (
+		seaLevelDensity    = 1.225   // kg/m^3
+		seaLevelTemp       = 288.15  // kelvin
+		lapseRate          = 0.0065  // kelvin/meter
+		tropopause         = 11000.0 // meters
+		tropopauseDensity  = 0.36392 // kg/m^3
+		troposphereExp     = 4.25588 // g*M/(R*L) - 1
+		stratosphereFactor = 1.5769e-4
+	)
+	if altitude < tropopause {
+		return seaLevelDensity * math.Pow(1.0-lapseRate*math.Max(altitude, 0.0)/seaLevelTemp, troposphereExp)
+	}
+	return tropopauseDensity * math.Exp(-stratosphereFactor*(altitude-tropopause))
+}
+
+// xTime returns the flight time in a vacuum until the projectile comes down to height h. When the
+// projectile never climbs above h, it comes down onto the slope at the top of its flight.
+func xTime(angle, v, h float64) float64 {
+	vy := math.Sin((2.0*math.Pi)*(angle/360.0)) * v
+	discriminant := vy*vy - 2.0*gravityAmps2*h
+	if discriminant < 0.0 {
+		return vy / gravityAmps2
+	}
+	return (vy + math.Sqrt(discriminant)) / gravityAmps2
+}
+
+// terrain is the height of the ground (meters, relative to the tank) along the approach path. The zero
+// value is level ground at the height of the tank.
+type terrain struct {
+	elevation float64   // meters, everywhere when there are no points
+	spacing   float64   // meters between points
+	points    []float64 // heights, starting at the tank
+}
+
+// newTerrain generates rolling hills up to roughness above or below elevation, out to distance. The
+// hills start past the tank, which stands on the ground at height 0.
+func newTerrain(r *rand.Rand, elevation, roughness, distance float64) terrain {
+	t := terrain{elevation: elevation}
+	if roughness <= 0.0 {
+		return t
+	}
+	t.spacing = distance / terrainSegments
+	t.points = []float64{0.0}
+	for i := 1; i <= terrainSegments; i++ {
+		t.points = append(t.points, elevation+getRandomValue(r, -roughness, roughness))
+	}
+	return t
+}
+
+func (t terrain) level() bool {
+	return len(t.points) == 0
+}
+
+// height returns the height of the ground at x, interpolating between the points.
+func (t terrain) height(x float64) float64 {
+	if t.level() {
+		return t.elevation
+	}
+	i := int(math.Floor(x / t.spacing))
+	if i < 0 {
+		return t.points[0]
+	}
+	if i >= len(t.points)-1 {
+		return t.points[len(t.points)-1]
+	}
+	fraction := x/t.spacing - float64(i)
+	return t.points[i] + fraction*(t.points[i+1]-t.points[i])
+}
+
+// span returns the lowest and highest points of the terrain.
+func (t terrain) span() (low, high float64) {
+	low, high = t.elevation, t.elevation
+	for i, h := range t.points {
+		if i == 0 || h < low {
+			low = h
+		}
+		if i == 0 || h > high {
+			high = h
+		}
+	}
+	return
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func Test_ballistics_flight(t *testing.T) {
+	drag, err := newDragModel(defaultDragCoefficient, defaultProjectileMass, defaultCaliber)
+	if err != nil {
+		t.Fatalf("newDragModel() error = %v", err)
+	}
+	tests := []struct {
+		name       string
+		ballistics ballistics
+		angle      float64
+		v          float64
+	}{
+		{
+			name:       "Vacuum",
+			ballistics: ballistics{},
+			angle:      22.5,
+			v:          300.0,
+		},
+		{
+			name:       "Drag - 300 m/s",
+			ballistics: ballistics{drag: drag},
+			angle:      22.5,
+			v:          300.0,
+		},
+		{
+			name:       "Drag - 600 m/s",
+			ballistics: ballistics{drag: drag},
+			angle:      40.0,
+			v:          600.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotX, gotT := tt.ballistics.flight(tt.angle, tt.v)
+			vacuumX, vacuumT := xRange(tt.angle, tt.v)
+			if tt.ballistics.drag == nil && (gotX != vacuumX || gotT != vacuumT) {
+				t.Errorf("flight() = %v, %v, want %v, %v", gotX, gotT, vacuumX, vacuumT)
+			}
+			if tt.ballistics.drag != nil && (gotX >= vacuumX || gotX <= 0.0 || gotT >= vacuumT) {
+				t.Errorf("flight() = %v, %v, want less than the vacuum %v, %v", gotX, gotT, vacuumX, vacuumT)
+			}
+			if gotAngle := tt.ballistics.angleFor(gotX, tt.v); math.Abs(gotAngle-tt.angle) > 1e-4 {
+				t.Errorf("angleFor() = %v, want %v", gotAngle, tt.angle)
+			}
+		})
+	}
+}
+
+func Test_ballistics_maxRange(t *testing.T) {
+	drag, _ := newDragModel(defaultDragCoefficient, defaultProjectileMass, defaultCaliber)
+	b := ballistics{drag: drag}
+	angle := b.maxRangeAngle(600.0)
+	if angle <= minShotAngle || angle >= maxShotAngle {
+		t.Errorf("maxRangeAngle() = %v, want between %v and %v", angle, minShotAngle, maxShotAngle)
+	}
+	maxX := b.maxRange(600.0)
+	for _, a := range []float64{angle - 1.0, angle + 1.0, maxShotAngle} {
+		if x, _ := b.flight(a, 600.0); x > maxX {
+			t.Errorf("flight(%v) = %v, want <= maxRange() = %v", a, x, maxX)
+		}
+	}
+	if got := b.angleFor(maxX+1.0, 600.0); !math.IsNaN(got) {
+		t.Errorf("angleFor() beyond maxRange() = %v, want NaN", got)
+	}
+}
+
+func Test_airDensity(t *testing.T) {
+	if got := airDensity(0.0); got != 1.225 {
+		t.Errorf("airDensity(0) = %v, want 1.225", got)
+	}
+	if below, above := airDensity(10999.9), airDensity(11000.0); math.Abs(below-above) > 1e-3 {
+		t.Errorf("airDensity() at the tropopause = %v, %v, want continuous", below, above)
+	}
+}
+
+func Test_ballistics_wind(t *testing.T) {
+	drag, _ := newDragModel(defaultDragCoefficient, defaultProjectileMass, defaultCaliber)
+	tests := []struct {
+		name string
+		drag *dragModel
+	}{
+		{
+			name: "Vacuum",
+			drag: nil,
+		},
+		{
+			name: "Drag",
+			drag: drag,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stillX, stillT := ballistics{drag: tt.drag}.flight(30.0, 400.0)
+			tailX, tailT := ballistics{drag: tt.drag, wind: 10.0}.flight(30.0, 400.0)
+			headX, headT := ballistics{drag: tt.drag, wind: -10.0}.flight(30.0, 400.0)
+			if !(headX < stillX && stillX < tailX) {
+				t.Errorf("flight() with head, no and tail wind = %v, %v, %v, want increasing", headX, stillX, tailX)
+			}
+			if tt.drag != nil && !(headT < stillT && stillT < tailT) {
+				t.Errorf("flight() times with head, no and tail wind = %v, %v, %v, want increasing", headT, stillT, tailT)
+			}
+			b := ballistics{drag: tt.drag, wind: -10.0}
+			if gotAngle := b.angleFor(headX, 400.0); math.Abs(gotAngle-30.0) > 1e-4 {
+				t.Errorf("angleFor() in a head wind = %v, want %v", gotAngle, 30.0)
+			}
+		})
+	}
+}
+
+func Test_ballistics_elevation(t *testing.T) {
+	drag, _ := newDragModel(defaultDragCoefficient, defaultProjectileMass, defaultCaliber)
+	hills := terrain{spacing: 1000.0, points: []float64{0.0, 200.0, -100.0, 300.0, 0.0}}
+	tests := []struct {
+		name string
+		drag *dragModel
+	}{
+		{
+			name: "Vacuum",
+			drag: nil,
+		},
+		{
+			name: "Drag",
+			drag: drag,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			levelX, levelT := ballistics{drag: tt.drag}.flight(20.0, 300.0)
+			highX, highT := ballistics{drag: tt.drag, terrain: terrain{elevation: 200.0}}.flight(20.0, 300.0)
+			lowX, lowT := ballistics{drag: tt.drag, terrain: terrain{elevation: -200.0}}.flight(20.0, 300.0)
+			if !(highX < levelX && levelX < lowX) || !(highT < levelT && levelT < lowT) {
+				t.Errorf("flight() onto high, level and low ground = %v/%v, %v/%v, %v/%v, want increasing", highX, highT, levelX, levelT, lowX, lowT)
+			}
+			b := ballistics{drag: tt.drag, terrain: hills}
+			for _, angle := range []float64{5.0, 25.0} {
+				x, _ := b.flight(angle, 300.0)
+				if x <= 0.0 {
+					t.Errorf("flight(%v) onto the hills = %v, want > 0", angle, x)
+				}
+			}
+		})
+	}
+}
+
+func Test_xTime(t *testing.T) {
+	_, levelT := xRange(30.0, 300.0)
+	if got := xTime(30.0, 300.0, 0.0); math.Abs(got-levelT) > 1e-9 {
+		t.Errorf("xTime() at level = %v, want %v", got, levelT)
+	}
+	// 300 m/s straight up only climbs to about 4589 meters.
+	if got, want := xTime(90.0, 300.0, 5000.0), 300.0/gravityAmps2; math.Abs(got-want) > 1e-9 {
+		t.Errorf("xTime() onto an unreachable height = %v, want the top of the flight %v", got, want)
+	}
+}
+
+func Test_newTerrain(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		terrain := newTerrain(rand.New(rand.NewSource(seed)), 100.0, 50.0, 10000.0)
+		if got := terrain.height(0.0); got != 0.0 {
+			t.Errorf("newTerrain() seed %d height at the tank = %v, want 0", seed, got)
+		}
+		if low, high := terrain.span(); low < 0.0 || high > 150.0 {
+			t.Errorf("newTerrain() seed %d span = %v to %v, want from 0 to 150", seed, low, high)
+		}
+	}
+}
+
+func Test_terrain_height(t *testing.T) {
+	hills := terrain{spacing: 100.0, points: []float64{0.0, 50.0, -50.0}}
+	tests := []struct {
+		name    string
+		terrain terrain
+		x       float64
+		want    float64
+	}{
+		{
+			name:    "Level",
+			terrain: terrain{elevation: 25.0},
+			x:       1234.0,
+			want:    25.0,
+		},
+		{
+			name:    "Between Points",
+			terrain: hills,
+			x:       150.0,
+			want:    0.0,
+		},
+		{
+			name:    "On a Point",
+			terrain: hills,
+			x:       100.0,
+			want:    50.0,
+		},
+		{
+			name:    "Past the Last Point",
+			terrain: hills,
+			x:       500.0,
+			want:    -50.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.terrain.height(tt.x); got != tt.want {
+				t.Errorf("height() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ballistics_highAngleFor(t *testing.T) {
+	drag, _ := newDragModel(defaultDragCoefficient, defaultProjectileMass, defaultCaliber)
+	tests := []struct {
+		name       string
+		ballistics ballistics
+	}{
+		{
+			name:       "Vacuum",
+			ballistics: ballistics{},
+		},
+		{
+			name:       "Drag",
+			ballistics: ballistics{drag: drag},
+		},
+		{
+			name:       "Hill",
+			ballistics: ballistics{terrain: terrain{elevation: 300.0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, lowT := tt.ballistics.flight(20.0, 400.0)
+			highAngle := tt.ballistics.highAngleFor(x, 400.0)
+			if highAngle <= maxShotAngle || highAngle > maxHighShotAngle {
+				t.Fatalf("highAngleFor() = %v, want between %v and %v", highAngle, maxShotAngle, maxHighShotAngle)
+			}
+			highX, highT := tt.ballistics.flight(highAngle, 400.0)
+			if math.Abs(highX-x) > 0.01 {
+				t.Errorf("flight(highAngleFor()) = %v, want %v", highX, x)
+			}
+			if highT <= lowT {
+				t.Errorf("flight(highAngleFor()) time = %v, want more than the low arc %v", highT, lowT)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultBatchGames    = 100
+	defaultBatchMaxShots = 100
+)
+
+// batchStats collects the results of every game that one strategy played.
+type batchStats struct {
+	Strategy  string
+	Games     int
+	Hits      int
+	Crushed   int
+	Destroyed int          // by the targets firing back
+	Failures  int          // out of ammunition
+	Shots     []float64    // shots to kill, for the hits
+	Times     []float64    // seconds to kill, for the hits
+	Closest   []float64    // closest target approach, for every game
+	Results   []gameResult // every game, in the order of the seeds
+}
+
+func (s *batchStats) add(r gameResult) {
+	s.Games++
+	switch r.Outcome {
+	case outcomeHit:
+		s.Hits++
+		s.Shots = append(s.Shots, float64(r.Shots))
+		s.Times = append(s.Times, r.Time)
+	case outcomeCrushed:
+		s.Crushed++
+	case outcomeDestroyed:
+		s.Destroyed++
+	default:
+		s.Failures++
+	}
+	s.Closest = append(s.Closest, r.TargetRange)
+	s.Results = append(s.Results, r)
+}
+
+// runBatch is the batch command: it plays seeded games without a display for every strategy named in
+// args, built-in or bot:, and prints how well each strategy did.
+func runBatch(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	configFlags := newConfigFlags(fs)
+	games := fs.Int("games", defaultBatchGames, "Number of Games per Strategy")
+	maxShots := fs.Int("shots", defaultBatchMaxShots, "Shots before a Game counts as a Failure")
+	fs.Parse(args)
+	config, err := configFlags.resolve()
+	if err != nil {
+		return err
+	}
+	config.MaxShots = *maxShots
+
+	names, err := getStrategyArgs(fs)
+	if err != nil {
+		return err
+	}
+	if *games <= 0 {
+		return fmt.Errorf("invalid number of games %d: want at least 1", *games)
+	}
+
+	fmt.Fprintf(out, "Batch: %d games per strategy, seeds %d to %d, up to %d shots per game\n", *games, config.Seed, config.Seed+int64(*games)-1, config.MaxShots)
+	for _, name := range names {
+		config.Strategy = name
+		stats, err := simulate(config, *games)
+		if err != nil {
+			return err
+		}
+		printBatchStats(out, stats, config.EnglishUnits)
+	}
+	return nil
+}
+
+// getStrategyArgs returns the strategies named after the options of fs, or every built-in strategy
+// when there are none. A bot command can have spaces and commas, so -strategy is rejected rather than
+// split or ignored.
+func getStrategyArgs(fs *flag.FlagSet) ([]string, error) {
+	if err := rejectFlags(fs, "name the strategies after the options, e.g. "+fs.Name()+" heuristic intercept", "strategy"); err != nil {
+		return nil, err
+	}
+	if fs.NArg() == 0 {
+		return strings.Split(getStrategyNames(), ", "), nil
+	}
+	return fs.Args(), nil
+}
+
+// simulate plays games in Auto Shot Mode at instant speed, seeded from config.Seed upwards, with the
+// display thrown away.
+func simulate(config Config, games int) (batchStats, error) {
+	config.ShootModeAuto = true
+	config.PrintShotProfile = false
+	config.Speed = "instant"
+	stats := batchStats{Strategy: config.Strategy}
+	seed := config.Seed
+	for i := 0; i < games; i++ {
+		config.Seed = seed + int64(i)
+		g, err := NewGame(config, strings.NewReader(""), io.Discard)
+		if err != nil {
+			return stats, err
+		}
+		g.Run(context.Background())
+		stats.add(g.result())
+	}
+	return stats, nil
+}
+
+func printBatchStats(out io.Writer, stats batchStats, englishUnits bool) {
+	closest := make([]float64, len(stats.Closest))
+	for i, x := range stats.Closest {
+		closest[i] = getFeetOrMeters(x, englishUnits)
+	}
+	fmt.Fprintln(out, "==================================")
+	fmt.Fprintf(out, "Strategy: %s\n", stats.Strategy)
+	destroyed := ""
+	if stats.Destroyed > 0 {
+		destroyed = fmt.Sprintf(", %d destroyed", stats.Destroyed)
+	}
+	fmt.Fprintf(out, "Win Rate         = %5.1f%% (%d hits, %d crushed%s, %d failures in %d games)\n", 100.0*float64(stats.Hits)/float64(stats.Games), stats.Hits, stats.Crushed, destroyed, stats.Failures, stats.Games)
+	fmt.Fprintf(out, "Shots to Kill    = %s\n", getDistributionText(stats.Shots, "%.0f", "shots"))
+	fmt.Fprintf(out, "Time to Kill     = %s\n", getDistributionText(stats.Times, "%.1f", "seconds"))
+	fmt.Fprintf(out, "Closest Approach = %s\n", getDistributionText(closest, "%.1f", feetOrMeters[englishUnits]))
+}
+
+// distribution summarizes a sample.
+type distribution struct {
+	Min, Median, Mean, P90, Max float64
+}
+
+func getDistribution(values []float64) distribution {
+	if len(values) == 0 {
+		return distribution{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	sum := 0.0
+	for _, x := range sorted {
+		sum += x
+	}
+	// Nearest rank percentiles.
+	percentile := func(p float64) float64 {
+		return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+	}
+	return distribution{
+		Min:    sorted[0],
+		Median: percentile(0.5),
+		Mean:   sum / float64(len(sorted)),
+		P90:    percentile(0.9),
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+func getDistributionText(values []float64, format, units string) string {
+	if len(values) == 0 {
+		return "---"
+	}
+	d := getDistribution(values)
+	f := func(x float64) string {
+		return fmt.Sprintf(format, x)
+	}
+	return fmt.Sprintf("min %s, median %s, mean %.1f, p90 %s, max %s %s", f(d.Min), f(d.Median), d.Mean, f(d.P90), f(d.Max), units)
+}
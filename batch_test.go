@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_getDistribution(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   distribution
+	}{
+		{
+			name:   "Empty",
+			values: nil,
+			want:   distribution{},
+		},
+		{
+			name:   "One Value",
+			values: []float64{3.0},
+			want:   distribution{Min: 3.0, Median: 3.0, Mean: 3.0, P90: 3.0, Max: 3.0},
+		},
+		{
+			name:   "Unsorted",
+			values: []float64{10.0, 1.0, 9.0, 2.0, 8.0, 3.0, 7.0, 4.0, 6.0, 5.0},
+			want:   distribution{Min: 1.0, Median: 5.0, Mean: 5.5, P90: 9.0, Max: 10.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getDistribution(tt.values); got != tt.want {
+				t.Errorf("getDistribution() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_simulate(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       Config
+		wantHits     int
+		wantFailures int
+	}{
+		{
+			name:     "Intercept Always Hits",
+			config:   withDefaults(Config{Strategy: "intercept", DeathRadius: impactRadius, Seed: 1}),
+			wantHits: 5,
+		},
+		{
+			name:         "Bisect Runs Out of Ammunition",
+			config:       withDefaults(Config{Strategy: "bisect", DeathRadius: impactRadius, Seed: 1, MaxShots: 1}),
+			wantFailures: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := simulate(tt.config, 5)
+			if err != nil {
+				t.Fatalf("simulate() error = %v", err)
+			}
+			if stats.Games != 5 || stats.Hits != tt.wantHits || stats.Failures != tt.wantFailures {
+				t.Errorf("simulate() = %+v, want %d hits and %d failures in 5 games", stats, tt.wantHits, tt.wantFailures)
+			}
+			if len(stats.Shots) != stats.Hits || len(stats.Times) != stats.Hits || len(stats.Closest) != stats.Games {
+				t.Errorf("simulate() = %+v, want a sample for every hit and every game", stats)
+			}
+		})
+	}
+}
+
+func Test_runBatch(t *testing.T) {
+	var out bytes.Buffer
+	if err := runBatch([]string{"-games", "3", "-seed", "7", "intercept", "blind"}, &out); err != nil {
+		t.Fatalf("runBatch() error = %v", err)
+	}
+	for _, want := range []string{"seeds 7 to 9", "Strategy: intercept", "Strategy: blind", "Win Rate         = 100.0%"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("runBatch() output = %q, want it to contain %q", out.String(), want)
+		}
+	}
+	if strings.Contains(out.String(), "Strategy: heuristic") {
+		t.Errorf("runBatch() output = %q, want only the selected strategies", out.String())
+	}
+}
+
+func Test_getStrategyArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "All built-in Strategies", args: []string{"-games", "3"}, want: strings.Split(getStrategyNames(), ", ")},
+		{name: "Named", args: []string{"intercept", "blind"}, want: []string{"intercept", "blind"}},
+		{name: "Bot with a Comma", args: []string{"heuristic", "bot:python3 mybot.py --aim 1,2"}, want: []string{"heuristic", "bot:python3 mybot.py --aim 1,2"}},
+		{name: "Strategy Flag", args: []string{"-strategy", "heuristic"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+			newConfigFlags(fs)
+			fs.Int("games", defaultBatchGames, "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := getStrategyArgs(fs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getStrategyArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getStrategyArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	mapWidth  = 41 // columns, twice the rows because a character is about twice as high as it is wide
+	mapHeight = 21
+)
+
+// battlefieldMap is a top-down view of the 2D battlefield with north up, the tank in the middle and the
+// max range of the projectile on the edge.
+type battlefieldMap struct {
+	cells    [mapHeight][mapWidth]byte
+	maxRange float64
+}
+
+func newBattlefieldMap(maxRange float64) *battlefieldMap {
+	m := &battlefieldMap{maxRange: maxRange}
+	for row := range m.cells {
+		for col := range m.cells[row] {
+			m.cells[row][col] = ' '
+		}
+	}
+	// The ring at the max range, and the tank in the middle of it.
+	for degrees := 0; degrees < 360; degrees += 3 {
+		m.mark(maxRange, float64(degrees), '.')
+	}
+	m.mark(0.0, 0.0, '@')
+	return m
+}
+
+// getMapCell returns where distance at bearing (degrees clockwise from north) is on the map. Anything
+// beyond the max range is drawn on the edge.
+func getMapCell(distance, bearing, maxRange float64) (row, col int) {
+	distance = math.Min(distance, maxRange)
+	radians := bearing * math.Pi / 180.0
+	x := distance * math.Sin(radians) / maxRange
+	y := distance * math.Cos(radians) / maxRange
+	col = mapWidth/2 + int(math.Round(x*float64(mapWidth/2)))
+	row = mapHeight/2 - int(math.Round(y*float64(mapHeight/2)))
+	return row, col
+}
+
+// mark draws c at distance and bearing from the tank, the tank itself is never drawn over.
+func (m *battlefieldMap) mark(distance, bearing float64, c byte) {
+	row, col := getMapCell(distance, bearing, m.maxRange)
+	if m.cells[row][col] == '@' {
+		return
+	}
+	m.cells[row][col] = c
+}
+
+func (m *battlefieldMap) String() string {
+	var b strings.Builder
+	border := "+" + strings.Repeat("-", mapWidth) + "+"
+	// N marks north above the column of the tank.
+	b.WriteString(border[:mapWidth/2+1] + "N" + border[mapWidth/2+2:] + "\n")
+	for _, row := range m.cells {
+		b.WriteString("|" + string(row[:]) + "|\n")
+	}
+	b.WriteString(border + "\n")
+	return b.String()
+}
+
+// printMap draws the 2D battlefield after a shot: the shot and the target that it was measured against,
+// every target that is left and the enemy shells that landed since the last map.
+func (g *Game) printMap(shot shotResult, hit bool) {
+	m := newBattlefieldMap(g.maxRange)
+	// An enemy shell lands on the line between the tank and the target that fired it.
+	for _, l := range g.incoming {
+		m.mark(math.Abs(l.miss), g.targets[l.target].bearing+getBearingOffset(l.miss), 'x')
+	}
+	g.incoming = nil
+	target := shot.Target - 1
+	for i, t := range g.targets {
+		if i != target && !t.destroyed {
+			m.mark(t.distance, t.bearing, g.getTargetMarker(i)[0])
+		}
+	}
+	t := g.targets[target]
+	if hit {
+		m.mark(t.distance, t.bearing, '*')
+	} else {
+		m.mark(shot.Range, shot.Azimuth, 'o')
+		m.mark(t.distance, t.bearing, g.getTargetMarker(target)[0])
+	}
+	fmt.Fprintln(g.out, "")
+	for _, row := range strings.SplitAfter(m.String(), "\n") {
+		fmt.Fprint(g.out, g.paintTimeline(row))
+	}
+	legend := "@ tank  T target  o shot  * hit"
+	if len(g.targets) > 1 {
+		legend = fmt.Sprintf("@ tank  1-%d targets  o shot  * hit", len(g.targets))
+	}
+	if g.config.ReturnFire {
+		legend += "  x incoming"
+	}
+	fmt.Fprintf(g.out, "%s  . max range %s\n", legend, g.getDisplayText(g.maxRange))
+	fmt.Fprintln(g.out, "")
+}
+
+// getBearingOffset turns an enemy shell that landed long (-) of the tank to the far side of it.
+func getBearingOffset(miss float64) float64 {
+	if miss < 0.0 {
+		return 180.0
+	}
+	return 0.0
+}
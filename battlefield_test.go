@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func Test_getMapCell(t *testing.T) {
+	tests := []struct {
+		name     string
+		distance float64
+		bearing  float64
+		wantRow  int
+		wantCol  int
+	}{
+		{
+			name:     "Tank",
+			distance: 0.0,
+			bearing:  0.0,
+			wantRow:  10,
+			wantCol:  20,
+		},
+		{
+			name:     "North",
+			distance: 1000.0,
+			bearing:  0.0,
+			wantRow:  0,
+			wantCol:  20,
+		},
+		{
+			name:     "East halfway",
+			distance: 500.0,
+			bearing:  90.0,
+			wantRow:  10,
+			wantCol:  30,
+		},
+		{
+			name:     "South beyond the max range",
+			distance: 2000.0,
+			bearing:  180.0,
+			wantRow:  20,
+			wantCol:  20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if row, col := getMapCell(tt.distance, tt.bearing, 1000.0); row != tt.wantRow || col != tt.wantCol {
+				t.Errorf("getMapCell() = %d, %d, want %d, %d", row, col, tt.wantRow, tt.wantCol)
+			}
+		})
+	}
+}
+
+func TestGame_getMiss(t *testing.T) {
+	tests := []struct {
+		name        string
+		bearing     float64
+		shotRange   float64
+		azimuth     float64
+		wantDelta   float64
+		wantLateral float64
+	}{
+		{
+			name:        "Straight at the target",
+			bearing:     0.0,
+			shotRange:   900.0,
+			azimuth:     0.0,
+			wantDelta:   100.0,
+			wantLateral: 0.0,
+		},
+		{
+			name:        "Target to the right",
+			bearing:     100.0,
+			shotRange:   1000.0,
+			azimuth:     10.0,
+			wantDelta:   -1000.0,
+			wantLateral: 1000.0,
+		},
+		{
+			name:        "Target to the left across north",
+			bearing:     350.0,
+			shotRange:   0.0,
+			azimuth:     80.0,
+			wantDelta:   0.0,
+			wantLateral: -1000.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Game{targets: []target{{distance: 1000.0, bearing: tt.bearing}}}
+			delta, lateral := g.getMiss(0, tt.shotRange, tt.azimuth)
+			if math.Abs(delta-tt.wantDelta) > 1e-9 || math.Abs(lateral-tt.wantLateral) > 1e-9 {
+				t.Errorf("getMiss() = %v, %v, want %v, %v", delta, lateral, tt.wantDelta, tt.wantLateral)
+			}
+		})
+	}
+}
+
+func TestGame_printImpactResults_2d(t *testing.T) {
+	tests := []struct {
+		name    string
+		azimuth float64
+		want    bool
+		wantOut string
+	}{
+		{
+			name:    "Hit",
+			azimuth: 45.0,
+			want:    true,
+			wantOut: "Direct hit",
+		},
+		{
+			name:    "Miss to the right",
+			azimuth: 50.0,
+			want:    false,
+			wantOut: "missed 87.2 meters to the right",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			g := &Game{config: Config{DeathRadius: impactRadius, TwoD: true}, out: &out, maxRange: 2000.0, targets: []target{{distance: 1000.0, bearing: 45.0}}}
+			shot := shotResult{Range: 1000.0, Azimuth: tt.azimuth, Target: 1}
+			shot.Delta, shot.Lateral = g.getMiss(0, shot.Range, shot.Azimuth)
+			if got := g.printImpactResults(shot, 1); got != tt.want {
+				t.Errorf("printImpactResults() = %v, want %v", got, tt.want)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) || !strings.Contains(out.String(), "N") {
+				t.Errorf("printImpactResults() printed %q, want %q and a map", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultBehavior = "constant"
+	evadeRange      = 250.0 // meters, a shot that lands closer to an evasive target makes it evade
+	evadeSeconds    = 5     // seconds that an evasive target evades for
+)
+
+// behavior decides how fast a target closes in on the tank, as a factor of its velocity. The factor
+// only changes on whole simulated seconds, so that a target moves the same way in real-time and when
+// it is fast forwarded over a shot. A behavior keeps its own state, so every target gets a new one.
+type behavior interface {
+	// factor returns the part of the target velocity that the target closes at during the second
+	// that starts at second, negative when it backs off. It always returns the same for a second.
+	factor(second int) float64
+	// shotLanded tells the behavior that a shot landed miss meters short (+) or long (-) of the
+	// target at the simulated time now.
+	shotLanded(now, miss float64)
+}
+
+var behaviors = map[string]func(r *rand.Rand) behavior{
+	"constant":     func(r *rand.Rand) behavior { return constantBehavior{} },
+	"accelerating": func(r *rand.Rand) behavior { return acceleratingBehavior{} },
+	"stop-and-go":  func(r *rand.Rand) behavior { return stopAndGoBehavior{} },
+	"random":       func(r *rand.Rand) behavior { return &randomBehavior{rand: r} },
+	"evasive":      func(r *rand.Rand) behavior { return &evasiveBehavior{rand: r} },
+}
+
+// newBehavior creates the behavior of a target, r is only used by the unpredictable behaviors.
+func newBehavior(name string, r *rand.Rand) (behavior, error) {
+	newFunc, ok := behaviors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown target behavior %q: want one of %s", name, getBehaviorNames())
+	}
+	return newFunc(r), nil
+}
+
+func getBehaviorNames() string {
+	var names []string
+	for name := range behaviors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// constantBehavior closes in at the target velocity all the time.
+type constantBehavior struct{}
+
+func (constantBehavior) factor(second int) float64 {
+	return 1.0
+}
+
+func (constantBehavior) shotLanded(now, miss float64) {}
+
+// acceleratingBehavior starts at half the target velocity and speeds up to twice the target
+// velocity over a minute.
+type acceleratingBehavior struct{}
+
+func (acceleratingBehavior) factor(second int) float64 {
+	return math.Min(0.5+float64(second)*1.5/60.0, 2.0)
+}
+
+func (acceleratingBehavior) shotLanded(now, miss float64) {}
+
+// stopAndGoBehavior drives for 10 seconds at one and a half times the target velocity, then stops for
+// 5 seconds, so that it keeps the target velocity on average.
+type stopAndGoBehavior struct{}
+
+func (stopAndGoBehavior) factor(second int) float64 {
+	if second%15 < 10 {
+		return 1.5
+	}
+	return 0.0
+}
+
+func (stopAndGoBehavior) shotLanded(now, miss float64) {}
+
+// randomBehavior changes to a random speed from standing still to twice the target velocity every 5
+// seconds.
+type randomBehavior struct {
+	rand    *rand.Rand
+	factors []float64 // drawn in order, one for every 5 seconds
+}
+
+func (b *randomBehavior) factor(second int) float64 {
+	for len(b.factors) <= second/5 {
+		b.factors = append(b.factors, getRandomValue(b.rand, 0.0, 2.0))
+	}
+	return b.factors[second/5]
+}
+
+func (b *randomBehavior) shotLanded(now, miss float64) {}
+
+// evasiveBehavior closes in at the target velocity until a shot lands within evadeRange of it. From
+// the next second it then either speeds up to twice the target velocity or backs off at the target
+// velocity for evadeSeconds.
+type evasiveBehavior struct {
+	rand        *rand.Rand
+	evadeFrom   int // the seconds from evadeFrom up to evadeUntil are spent evading
+	evadeUntil  int
+	evadeFactor float64
+}
+
+func (b *evasiveBehavior) factor(second int) float64 {
+	if second >= b.evadeFrom && second < b.evadeUntil {
+		return b.evadeFactor
+	}
+	return 1.0
+}
+
+func (b *evasiveBehavior) shotLanded(now, miss float64) {
+	if math.Abs(miss) > evadeRange {
+		return
+	}
+	b.evadeFrom = int(math.Floor(now)) + 1
+	b.evadeUntil = b.evadeFrom + evadeSeconds
+	b.evadeFactor = 2.0
+	if b.rand.Intn(2) == 0 {
+		b.evadeFactor = -1.0
+	}
+}
+
+// travel returns how far t closes in on the tank in the elapsed seconds after start.
+func (t *target) travel(start, elapsed float64) float64 {
+	if t.behavior == nil {
+		// Without a behavior, the target closes in at its velocity.
+		return t.vmps * elapsed
+	}
+	distance := 0.0
+	for elapsed > 0.0 {
+		second := int(math.Floor(start))
+		factor := t.behavior.factor(second)
+		// Take all the following seconds at the same factor in one step.
+		end := float64(second + 1)
+		for end < start+elapsed && t.behavior.factor(int(end)) == factor {
+			end++
+		}
+		step := math.Min(end-start, elapsed)
+		distance += t.vmps * factor * step
+		start += step
+		elapsed -= step
+	}
+	return distance
+}
+
+// factorAt returns the factor of its velocity that t closes in at, at the simulated time now.
+func (t *target) factorAt(now float64) float64 {
+	if t.behavior == nil {
+		return 1.0
+	}
+	return t.behavior.factor(int(math.Floor(now)))
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func Test_newBehavior(t *testing.T) {
+	for _, name := range strings.Split(getBehaviorNames(), ", ") {
+		if _, err := newBehavior(name, rand.New(rand.NewSource(1))); err != nil {
+			t.Errorf("newBehavior(%q) error = %v", name, err)
+		}
+	}
+	if _, err := newBehavior("teleporting", nil); err == nil {
+		t.Errorf("newBehavior() with an unknown name, want an error")
+	}
+}
+
+func Test_behavior_factor(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior behavior
+		seconds  []int
+		want     []float64
+	}{
+		{
+			name:     "Constant",
+			behavior: constantBehavior{},
+			seconds:  []int{0, 10, 100},
+			want:     []float64{1.0, 1.0, 1.0},
+		},
+		{
+			name:     "Accelerating",
+			behavior: acceleratingBehavior{},
+			seconds:  []int{0, 20, 60, 120},
+			want:     []float64{0.5, 1.0, 2.0, 2.0},
+		},
+		{
+			name:     "Stop and Go",
+			behavior: stopAndGoBehavior{},
+			seconds:  []int{0, 9, 10, 14, 15},
+			want:     []float64{1.5, 1.5, 0.0, 0.0, 1.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, second := range tt.seconds {
+				if got := tt.behavior.factor(second); got != tt.want[i] {
+					t.Errorf("factor(%d) = %v, want %v", second, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_randomBehavior(t *testing.T) {
+	b := &randomBehavior{rand: rand.New(rand.NewSource(1))}
+	// The factors are drawn in order, whichever second is asked for first.
+	late := b.factor(12)
+	c := &randomBehavior{rand: rand.New(rand.NewSource(1))}
+	for second := 0; second < 15; second++ {
+		factor := c.factor(second)
+		if factor < 0.0 || factor > 2.0 {
+			t.Errorf("factor(%d) = %v, want from 0 to 2", second, factor)
+		}
+		if second/5 == 12/5 && factor != late {
+			t.Errorf("factor(%d) = %v, want %v", second, factor, late)
+		}
+	}
+}
+
+func Test_evasiveBehavior(t *testing.T) {
+	tests := []struct {
+		name      string
+		miss      float64
+		wantEvade bool
+	}{
+		{
+			name:      "Near Miss",
+			miss:      -100.0,
+			wantEvade: true,
+		},
+		{
+			name:      "Far Miss",
+			miss:      1000.0,
+			wantEvade: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &evasiveBehavior{rand: rand.New(rand.NewSource(1))}
+			b.shotLanded(30.5, tt.miss)
+			if got := b.factor(30); got != 1.0 {
+				t.Errorf("factor(30) = %v, want 1 until the next second", got)
+			}
+			for second := 31; second < 31+evadeSeconds; second++ {
+				if evading := b.factor(second) != 1.0; evading != tt.wantEvade {
+					t.Errorf("factor(%d) = %v, want evading = %v", second, b.factor(second), tt.wantEvade)
+				}
+			}
+			if got := b.factor(31 + evadeSeconds); got != 1.0 {
+				t.Errorf("factor(%d) = %v, want 1 after evading", 31+evadeSeconds, got)
+			}
+		})
+	}
+}
+
+func Test_target_travel(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior behavior
+		start    float64
+		elapsed  float64
+		want     float64
+	}{
+		{
+			name:     "Constant",
+			behavior: constantBehavior{},
+			start:    0.3,
+			elapsed:  23.4,
+			want:     10.0 * 23.4,
+		},
+		{
+			name:     "Stop and Go across a stop",
+			behavior: stopAndGoBehavior{},
+			start:    8.5,
+			elapsed:  8.0,
+			want:     10.0 * 1.5 * (1.5 + 1.5),
+		},
+		{
+			name:     "Accelerating within a second",
+			behavior: acceleratingBehavior{},
+			start:    20.25,
+			elapsed:  0.5,
+			want:     10.0 * 1.0 * 0.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &target{vmps: 10.0, behavior: tt.behavior}
+			if got := target.travel(tt.start, tt.elapsed); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("travel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// The targets must move the same way whether they move every second or are fast forwarded over a shot.
+func Test_target_travel_steps(t *testing.T) {
+	for _, name := range strings.Split(getBehaviorNames(), ", ") {
+		t.Run(name, func(t *testing.T) {
+			newTarget := func() *target {
+				b, err := newBehavior(name, rand.New(rand.NewSource(1)))
+				if err != nil {
+					t.Fatalf("newBehavior() error = %v", err)
+				}
+				return &target{vmps: 10.0, behavior: b}
+			}
+			whole, steps := newTarget(), newTarget()
+			want := whole.travel(0.0, 90.0)
+			got := 0.0
+			for second := 0; second < 90; second++ {
+				got += steps.travel(float64(second), 1.0)
+			}
+			if math.Abs(got-want) > 1e-9 {
+				t.Errorf("travel() in steps = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestGame_Run_behaviors(t *testing.T) {
+	for _, name := range strings.Split(getBehaviorNames(), ", ") {
+		t.Run(name, func(t *testing.T) {
+			config := withDefaults(Config{ShootModeAuto: true, DeathRadius: impactRadius, Speed: "instant", Seed: 1, MaxShots: 100, TargetBehavior: name})
+			g, err := NewGame(config, strings.NewReader(""), io.Discard)
+			if err != nil {
+				t.Fatalf("NewGame() error = %v", err)
+			}
+			g.Run(context.Background())
+			if got := g.result().Outcome; got == outcomeNone || got == outcomeQuit {
+				t.Errorf("Run() outcome = %q, want the game to be over", got)
+			}
+		})
+	}
+}
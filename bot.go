@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	botPrefix         = "bot:" // a strategy name such as bot:./mybot or bot:tcp:localhost:9000
+	botTCPPrefix      = "tcp:"
+	defaultBotTimeout = 5.0 // seconds that a bot has to reply
+	maxBotFailures    = 3   // failures in a row before the game gives up on a bot
+	maxBotReplyText   = 40  // characters of a bad reply that are reported
+	botFallback       = "heuristic"
+)
+
+// errBotHungUp is returned once the bot closed its end of the connection, it never comes back.
+var errBotHungUp = errors.New("the bot hung up")
+
+// botRequest is the line that the tank sends to a bot before every shot: the observation, with the
+// result of the last shot pulled out for convenience.
+type botRequest struct {
+	Shot     int         `json:"shot"`      // the shot that the bot is asked for, numbered from 1
+	LastShot *shotResult `json:"last_shot"` // null before the first shot
+	observation
+}
+
+// botReply is the line that a bot answers with. A bot that echoes the shot has its late replies
+// to earlier shots ignored.
+type botReply struct {
+	Shot  int      `json:"shot"`
+	Angle *float64 `json:"angle"`
+}
+
+// botStrategy asks an external program for the next shot angle. The program is started, or
+// connected to, before the first shot and exchanges one line of JSON per shot with the tank. When the
+// bot doesn't answer in time or answers nonsense, the heuristic strategy takes the shot instead.
+type botStrategy struct {
+	spec     string // the command line, or tcp: and the address
+	timeout  time.Duration
+	log      io.Writer // where the failures of the bot are reported
+	fallback strategy
+	failures int // in a row
+	gaveUp   bool
+
+	conn    io.WriteCloser
+	cmd     *exec.Cmd
+	replies chan string
+	done    chan struct{}
+}
+
+// newBotStrategy checks the spec of a bot without starting it.
+func newBotStrategy(spec string) (*botStrategy, error) {
+	spec = strings.TrimSpace(spec)
+	if addr := strings.TrimPrefix(spec, botTCPPrefix); addr != spec {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("invalid bot address %q: %v", addr, err)
+		}
+	} else if fields := strings.Fields(spec); len(fields) == 0 {
+		return nil, fmt.Errorf("invalid bot %q: want %s<command> or %s%s<host:port>", spec, botPrefix, botPrefix, botTCPPrefix)
+	} else if _, err := exec.LookPath(fields[0]); err != nil {
+		return nil, fmt.Errorf("invalid bot %q: %v", spec, err)
+	}
+	fallback, _ := newStrategy(botFallback)
+	return &botStrategy{
+		spec:     spec,
+		timeout:  time.Duration(defaultBotTimeout * float64(time.Second)),
+		log:      io.Discard,
+		fallback: fallback,
+	}, nil
+}
+
+func (b *botStrategy) nextAngle(obs observation) float64 {
+	if !b.gaveUp {
+		angle, err := b.ask(obs)
+		if err == nil {
+			b.failures = 0
+			return angle
+		}
+		b.failures++
+		fmt.Fprintf(b.log, "Bot %s: %v, the %s strategy takes shot #%d.\n", b.spec, err, botFallback, len(obs.Shots)+1)
+		if b.gaveUp || b.failures >= maxBotFailures || err == errBotHungUp {
+			fmt.Fprintf(b.log, "Bot %s: giving up on the bot, the %s strategy takes the rest of the shots.\n", b.spec, botFallback)
+			b.gaveUp = true
+			b.Close()
+		}
+	}
+	return b.fallback.nextAngle(obs)
+}
+
+// ask sends obs to the bot and waits for its angle.
+func (b *botStrategy) ask(obs observation) (float64, error) {
+	if b.conn == nil {
+		if err := b.start(); err != nil {
+			b.gaveUp = true
+			return 0.0, fmt.Errorf("can't start the bot: %v", err)
+		}
+	}
+	shot := len(obs.Shots) + 1
+	request := botRequest{Shot: shot, observation: obs}
+	if len(obs.Shots) > 0 {
+		request.LastShot = &obs.Shots[len(obs.Shots)-1]
+	}
+	line, err := json.Marshal(request)
+	if err != nil {
+		return 0.0, err
+	}
+	timer := time.NewTimer(b.timeout)
+	defer timer.Stop()
+	// A bot that doesn't read can block the write, so it runs out of time like a bot that doesn't answer.
+	written := make(chan error, 1)
+	go func(conn io.Writer) {
+		_, err := conn.Write(append(line, '\n'))
+		written <- err
+	}(b.conn)
+	for {
+		select {
+		case err := <-written:
+			if err != nil {
+				return 0.0, errBotHungUp
+			}
+			written = nil
+		case line, ok := <-b.replies:
+			if !ok {
+				return 0.0, errBotHungUp
+			}
+			var reply botReply
+			if err := json.Unmarshal([]byte(line), &reply); err != nil {
+				return 0.0, fmt.Errorf("invalid reply %q: %v", getBotReplyText(line), err)
+			}
+			if reply.Shot != 0 && reply.Shot != shot {
+				continue
+			}
+			maxAngle := maxShotAngle
+			if obs.HighAngle {
+				maxAngle = maxHighShotAngle
+			}
+			switch {
+			case reply.Angle == nil:
+				return 0.0, fmt.Errorf("invalid reply %q: want an angle", getBotReplyText(line))
+			case math.IsNaN(*reply.Angle) || *reply.Angle < minShotAngle || *reply.Angle > maxAngle:
+				return 0.0, fmt.Errorf("invalid angle %g: want %3.1f to %3.1f degrees", *reply.Angle, minShotAngle, maxAngle)
+			}
+			return *reply.Angle, nil
+		case <-timer.C:
+			return 0.0, fmt.Errorf("no reply within %v", b.timeout)
+		}
+	}
+}
+
+// getBotReplyText shortens a reply for an error message.
+func getBotReplyText(line string) string {
+	if len(line) > maxBotReplyText {
+		return line[:maxBotReplyText] + "..."
+	}
+	return line
+}
+
+// start runs the command of the bot, or connects to its address.
+func (b *botStrategy) start() error {
+	var r io.Reader
+	if addr := strings.TrimPrefix(b.spec, botTCPPrefix); addr != b.spec {
+		conn, err := net.DialTimeout("tcp", addr, b.timeout)
+		if err != nil {
+			return err
+		}
+		b.conn, r = conn, conn
+	} else {
+		fields := strings.Fields(b.spec)
+		cmd := exec.Command(fields[0], fields[1:]...)
+		// Whatever the bot logs goes straight to the player.
+		cmd.Stderr = os.Stderr
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return err
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		b.cmd, b.conn, r = cmd, stdin, stdout
+	}
+
+	replies, done := make(chan string), make(chan struct{})
+	go func() {
+		defer close(replies)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			select {
+			case replies <- scanner.Text():
+			case <-done:
+				return
+			}
+		}
+	}()
+	b.replies, b.done = replies, done
+	return nil
+}
+
+// Close hangs up on the bot. A bot command gets as long as a reply to exit before it is killed.
+func (b *botStrategy) Close() error {
+	if b.conn == nil {
+		return nil
+	}
+	close(b.done)
+	err := b.conn.Close()
+	if b.cmd != nil {
+		exited := make(chan error, 1)
+		go func() {
+			exited <- b.cmd.Wait()
+		}()
+		select {
+		case <-exited:
+		case <-time.After(b.timeout):
+			b.cmd.Process.Kill()
+			<-exited
+		}
+	}
+	b.conn, b.cmd = nil, nil
+	return err
+}
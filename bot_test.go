@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startFakeBot serves a single bot connection on localhost, answering every request with reply.
+func startFakeBot(t *testing.T, reply func(request botRequest) string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			var request botRequest
+			if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+				return
+			}
+			answer := reply(request)
+			if answer == "hang up" {
+				return
+			}
+			if answer != "" {
+				fmt.Fprintln(conn, answer)
+			}
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func Test_botStrategy_nextAngle(t *testing.T) {
+	obs := observation{ProjectileVmps: 400.0, MaxRange: 16000.0, Target: 1, TargetRange: 8000.0, TargetVmps: 10.0}
+	fallback := heuristicStrategy{}.nextAngle(obs)
+	tests := []struct {
+		name         string
+		reply        string
+		want         float64
+		wantFailures int
+	}{
+		{
+			name:  "Angle",
+			reply: `{"shot":1,"angle":30}`,
+			want:  30.0,
+		},
+		{
+			name:  "Angle without the shot",
+			reply: `{"angle":12.5}`,
+			want:  12.5,
+		},
+		{
+			name:  "A late reply to an earlier shot is ignored",
+			reply: "{\"shot\":7,\"angle\":10}\n{\"shot\":1,\"angle\":30}",
+			want:  30.0,
+		},
+		{
+			name:         "Not JSON",
+			reply:        "thirty degrees",
+			want:         fallback,
+			wantFailures: 1,
+		},
+		{
+			name:         "No angle",
+			reply:        `{"shot":1}`,
+			want:         fallback,
+			wantFailures: 1,
+		},
+		{
+			name:         "Angle out of range",
+			reply:        `{"shot":1,"angle":60}`,
+			want:         fallback,
+			wantFailures: 1,
+		},
+		{
+			name:         "No reply",
+			want:         fallback,
+			wantFailures: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := startFakeBot(t, func(botRequest) string { return tt.reply })
+			b, err := newBotStrategy(botTCPPrefix + addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer b.Close()
+			b.timeout = 100 * time.Millisecond
+			if got := b.nextAngle(obs); got != tt.want {
+				t.Errorf("botStrategy.nextAngle() = %v, want %v", got, tt.want)
+			}
+			if b.failures != tt.wantFailures {
+				t.Errorf("botStrategy.failures = %v, want %v", b.failures, tt.wantFailures)
+			}
+		})
+	}
+}
+
+func Test_botStrategy_gaveUp(t *testing.T) {
+	tests := []struct {
+		name       string
+		reply      string
+		wantShots  int // shots that the bot was asked for
+		wantGaveUp bool
+	}{
+		{
+			name:      "A good bot is asked every shot",
+			reply:     `{"angle":20}`,
+			wantShots: maxBotFailures + 1,
+		},
+		{
+			name:       "Too many failures in a row",
+			reply:      "?",
+			wantShots:  maxBotFailures,
+			wantGaveUp: true,
+		},
+		{
+			name:       "Hung up",
+			reply:      "hang up",
+			wantShots:  1,
+			wantGaveUp: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asked := make(chan int, maxBotFailures+1)
+			addr := startFakeBot(t, func(request botRequest) string {
+				asked <- request.Shot
+				return tt.reply
+			})
+			b, err := newBotStrategy(botTCPPrefix + addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer b.Close()
+			var log strings.Builder
+			b.log, b.timeout = &log, 100*time.Millisecond
+			obs := observation{ProjectileVmps: 400.0, TargetRange: 8000.0}
+			for i := 0; i <= maxBotFailures; i++ {
+				b.nextAngle(obs)
+				obs.Shots = append(obs.Shots, shotResult{Angle: 20.0})
+			}
+			if len(asked) != tt.wantShots {
+				t.Errorf("bot was asked for %d shots, want %d", len(asked), tt.wantShots)
+			}
+			if b.gaveUp != tt.wantGaveUp || strings.Contains(log.String(), "giving up") != tt.wantGaveUp {
+				t.Errorf("botStrategy.gaveUp = %v, want %v, log:\n%s", b.gaveUp, tt.wantGaveUp, log.String())
+			}
+		})
+	}
+}
+
+func Test_newBotStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{name: "Command", spec: os.Args[0] + " -test.run=none"},
+		{name: "Address", spec: "tcp:localhost:9000"},
+		{name: "No command", spec: " ", wantErr: true},
+		{name: "Unknown command", spec: "no-such-tank-bot", wantErr: true},
+		{name: "Address without a port", spec: "tcp:localhost", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newStrategy(botPrefix + tt.spec); (err != nil) != tt.wantErr {
+				t.Errorf("newStrategy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestBotHelperProcess is the bot command of TestGame_Run_bot, it shoots like the intercept strategy.
+func TestBotHelperProcess(t *testing.T) {
+	if os.Getenv("TANK_BOT_HELPER") != "1" {
+		t.Skip("only run as a bot")
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var request botRequest
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			os.Exit(1)
+		}
+		fmt.Printf("{\"shot\":%d,\"angle\":%v}\n", request.Shot, interceptStrategy{}.nextAngle(request.observation))
+	}
+	os.Exit(0)
+}
+
+func TestGame_Run_bot(t *testing.T) {
+	t.Setenv("TANK_BOT_HELPER", "1")
+	bot := botPrefix + os.Args[0] + " -test.run=^TestBotHelperProcess$"
+	var results [2]gameResult
+	for i, name := range []string{"intercept", bot} {
+		config := withDefaults(Config{ShootModeAuto: true, Strategy: name, Seed: 1, Speed: "instant", DeathRadius: impactRadius})
+		g, err := NewGame(config, strings.NewReader(""), io.Discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Run(context.Background())
+		results[i] = g.result()
+	}
+	if results[1].Outcome != outcomeHit || results[1].Shots != results[0].Shots {
+		t.Errorf("bot result = %+v, want the intercept result %+v", results[1], results[0])
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Clock measures and waits on simulation time, which lets a game run in real-time,
+// faster than real-time, or instantly.
+type Clock interface {
+	// Now returns the simulation time elapsed since the clock was created.
+	Now() time.Duration
+	// Sleep blocks until d of simulation time has elapsed or ctx is done.
+	Sleep(ctx context.Context, d time.Duration) error
+	// Await runs f, which blocks on something other than the clock, such as the player. A clock
+	// that runs in real-time keeps going meanwhile, the others stop until f returns.
+	Await(f func())
+}
+
+// newClock returns the clock for a speed multiplier, shared by participants goroutines. An infinite
+// multiplier is simulated instantly by a clock that advances whenever all of its participants are
+// asleep. Any other multiplier is paced in real-time, and the caller has to run the pacedClock.
+func newClock(speedMultiplier float64, participants int) Clock {
+	if math.IsInf(speedMultiplier, 1) {
+		return newInstantClock(participants)
+	}
+	return newPacedClock(participants, speedMultiplier)
+}
+
+// parseSpeedMultiplier converts a speed such as "10" or "instant" into a multiplier of real-time.
+func parseSpeedMultiplier(speed string) (float64, error) {
+	if speed == "instant" {
+		return math.Inf(1), nil
+	}
+	multiplier, err := strconv.ParseFloat(speed, 64)
+	if err != nil || multiplier <= 0.0 || math.IsInf(multiplier, 0) {
+		return 0.0, fmt.Errorf("invalid speed %q: want a positive multiplier or \"instant\"", speed)
+	}
+	return multiplier, nil
+}
+
+func getSpeedText(speedMultiplier float64) string {
+	if math.IsInf(speedMultiplier, 1) {
+		return "instant"
+	}
+	return fmt.Sprintf("%gx real-time", speedMultiplier)
+}
+
+// manualClock is a virtual clock that only moves when it is advanced. With participants > 0 it
+// advances by itself to the next wake up time as soon as that many goroutines are sleeping on it.
+// Then it wakes one sleeper at a time, the earliest first, so the goroutines always take turns in
+// the same order.
+type manualClock struct {
+	mu           sync.Mutex
+	changed      *sync.Cond
+	now          time.Duration
+	sleepers     []*sleeper // in the order they fell asleep
+	participants int
+	paced        bool // true = a pacedClock advances the clock instead of the sleepers
+}
+
+type sleeper struct {
+	wake time.Duration
+	done chan struct{}
+}
+
+func newManualClock() *manualClock {
+	c := &manualClock{}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+func newInstantClock(participants int) *manualClock {
+	c := newManualClock()
+	c.participants = participants
+	return c
+}
+
+func (c *manualClock) Now() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	c.mu.Lock()
+	s := &sleeper{wake: c.now + d, done: make(chan struct{})}
+	c.sleepers = append(c.sleepers, s)
+	c.changed.Broadcast()
+	if !c.paced && c.participants > 0 && len(c.sleepers) >= c.participants {
+		c.wakeNext()
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		c.remove(s)
+		c.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Await runs f. The clock doesn't move while f is blocked.
+func (c *manualClock) Await(f func()) {
+	f()
+}
+
+// Advance moves the clock forward by d, waking every sleeper that is due.
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advanceTo(c.now + d)
+}
+
+// BlockUntil waits until n goroutines are sleeping on the clock.
+func (c *manualClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.sleepers) < n {
+		c.changed.Wait()
+	}
+}
+
+// waitAsleep waits until all participants are sleeping on the clock, or ctx is done.
+func (c *manualClock) waitAsleep(ctx context.Context) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.mu.Lock()
+			c.changed.Broadcast()
+			c.mu.Unlock()
+		case <-done:
+		}
+	}()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.sleepers) < c.participants {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c.changed.Wait()
+	}
+	return nil
+}
+
+// nextSleeper returns the index of the sleeper that wakes up first.
+func (c *manualClock) nextSleeper() int {
+	next := 0
+	for i, s := range c.sleepers {
+		if s.wake < c.sleepers[next].wake {
+			next = i
+		}
+	}
+	return next
+}
+
+// wakeNext advances the clock to the first wake up and wakes that sleeper only.
+func (c *manualClock) wakeNext() {
+	s := c.sleepers[c.nextSleeper()]
+	c.now = s.wake
+	close(s.done)
+	c.remove(s)
+}
+
+func (c *manualClock) advanceTo(now time.Duration) {
+	c.now = now
+	sleepers := c.sleepers[:0]
+	for _, s := range c.sleepers {
+		if s.wake <= c.now {
+			close(s.done)
+		} else {
+			sleepers = append(sleepers, s)
+		}
+	}
+	c.sleepers = sleepers
+	c.changed.Broadcast()
+}
+
+func (c *manualClock) remove(s *sleeper) {
+	for i := range c.sleepers {
+		if c.sleepers[i] == s {
+			c.sleepers = append(c.sleepers[:i], c.sleepers[i+1:]...)
+			c.changed.Broadcast()
+			return
+		}
+	}
+}
+
+// pacedClock is an instant clock that waits until each wake up is due in real-time, speed times
+// faster. However late the goroutines are, they take turns exactly like on the instant clock, so a
+// game plays the same way at every speed. The clock can be paused and its speed changed while it
+// runs.
+type pacedClock struct {
+	*manualClock
+	pace        sync.Mutex // guards speed, paused and paceChanged
+	speed       float64    // times faster than real-time, +Inf = instant
+	paused      bool
+	paceChanged chan struct{} // closed whenever speed or paused change
+}
+
+func newPacedClock(participants int, speed float64) *pacedClock {
+	c := &pacedClock{manualClock: newInstantClock(participants), speed: speed, paceChanged: make(chan struct{})}
+	c.paced = true
+	return c
+}
+
+// Await runs f without holding up the other participants, the clock keeps going in real-time.
+func (c *pacedClock) Await(f func()) {
+	c.mu.Lock()
+	c.participants--
+	c.changed.Broadcast()
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.participants++
+		c.mu.Unlock()
+	}()
+	f()
+}
+
+// run advances the clock until ctx is done.
+func (c *pacedClock) run(ctx context.Context) {
+	for {
+		if c.waitAsleep(ctx) != nil {
+			return
+		}
+		c.mu.Lock()
+		d := c.sleepers[c.nextSleeper()].wake - c.now
+		c.mu.Unlock()
+		if c.wait(ctx, d) != nil {
+			return
+		}
+		c.mu.Lock()
+		// A participant may have come back from Await in the meantime.
+		if len(c.sleepers) > 0 && len(c.sleepers) >= c.participants {
+			c.wakeNext()
+		}
+		c.mu.Unlock()
+	}
+}
+
+// wait blocks for d of simulation time in real-time, stretched by pauses and speed changes.
+func (c *pacedClock) wait(ctx context.Context, d time.Duration) error {
+	for {
+		speed, paused, changed := c.state()
+		if paused {
+			select {
+			case <-changed:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if d <= 0 || math.IsInf(speed, 1) {
+			return nil
+		}
+		start := time.Now()
+		timer := time.NewTimer(time.Duration(float64(d) / speed))
+		select {
+		case <-timer.C:
+			return nil
+		case <-changed:
+			timer.Stop()
+			d -= time.Duration(float64(time.Since(start)) * speed)
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *pacedClock) state() (speed float64, paused bool, changed <-chan struct{}) {
+	c.pace.Lock()
+	defer c.pace.Unlock()
+	return c.speed, c.paused, c.paceChanged
+}
+
+// update changes the speed and the pause of the clock with f.
+func (c *pacedClock) update(f func(speed *float64, paused *bool)) {
+	c.pace.Lock()
+	defer c.pace.Unlock()
+	f(&c.speed, &c.paused)
+	close(c.paceChanged)
+	c.paceChanged = make(chan struct{})
+}
+
+// animatedClock stretches every Sleep of a clock into real-time, speed times faster, and moves Now
+// smoothly meanwhile. It lets a live display show the flight of a shot in a game whose targets pause,
+// where the flight would be over instantly.
+type animatedClock struct {
+	Clock
+	speed float64
+
+	mu    sync.Mutex // guards from, start and d
+	from  time.Duration
+	start time.Time // in real-time, zero = never slept
+	d     time.Duration
+}
+
+func newAnimatedClock(c Clock, speed float64) *animatedClock {
+	return &animatedClock{Clock: c, speed: speed}
+}
+
+func (c *animatedClock) Now() time.Duration {
+	now := c.Clock.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.start.IsZero() {
+		return now
+	}
+	animated := c.from + time.Duration(math.Min(float64(time.Since(c.start))*c.speed, float64(c.d)))
+	if animated > now {
+		return animated
+	}
+	return now
+}
+
+func (c *animatedClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.mu.Lock()
+	c.from, c.start, c.d = c.Clock.Now(), time.Now(), d
+	c.mu.Unlock()
+	timer := time.NewTimer(time.Duration(float64(d) / c.speed))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return c.Clock.Sleep(ctx, d)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
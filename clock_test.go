@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_parseSpeedMultiplier(t *testing.T) {
+	tests := []struct {
+		name    string
+		speed   string
+		want    float64
+		wantErr bool
+	}{
+		{
+			name:  "Multiplier",
+			speed: "2.5",
+			want:  2.5,
+		},
+		{
+			name:  "Instant",
+			speed: "instant",
+			want:  math.Inf(1),
+		},
+		{
+			name:    "Zero",
+			speed:   "0",
+			wantErr: true,
+		},
+		{
+			name:    "Not a Number",
+			speed:   "fast",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSpeedMultiplier(tt.speed)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseSpeedMultiplier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseSpeedMultiplier() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_manualClock(t *testing.T) {
+	clock := newManualClock()
+	woke := make(chan error)
+	go func() {
+		woke <- clock.Sleep(context.Background(), 3*time.Second)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(2 * time.Second)
+	select {
+	case <-woke:
+		t.Fatal("Sleep() returned before its wake up time")
+	default:
+	}
+	clock.Advance(time.Second)
+	if err := <-woke; err != nil {
+		t.Errorf("Sleep() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		woke <- clock.Sleep(ctx, time.Second)
+	}()
+	clock.BlockUntil(1)
+	cancel()
+	if err := <-woke; err != context.Canceled {
+		t.Errorf("Sleep() error = %v, want %v", err, context.Canceled)
+	}
+	if got := clock.Now(); got != 3*time.Second {
+		t.Errorf("Now() = %v, want %v", got, 3*time.Second)
+	}
+}
+
+// testTakingTurns runs two participants on clock, sleeping 3 and 2 seconds at a time until 6 seconds,
+// and returns who woke up when. Like in a game, the first one to finish stops the other.
+func testTakingTurns(clock Clock) string {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if c, ok := clock.(*pacedClock); ok {
+		go c.run(ctx)
+	}
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	for _, name := range []string{"a", "b"} {
+		d := map[string]time.Duration{"a": 3 * time.Second, "b": 2 * time.Second}[name]
+		wg.Add(1)
+		go func(name string, d time.Duration) {
+			defer wg.Done()
+			defer cancel()
+			for elapsed := d; elapsed <= 6*time.Second; elapsed += d {
+				if clock.Sleep(ctx, d) != nil {
+					return
+				}
+				mu.Lock()
+				order = append(order, fmt.Sprintf("%s@%v", name, clock.Now()))
+				mu.Unlock()
+			}
+		}(name, d)
+	}
+	wg.Wait()
+	return fmt.Sprint(order)
+}
+
+func Test_instantClock(t *testing.T) {
+	// Both participants want to wake up at 6 seconds, the one that fell asleep first goes first.
+	want := "[b@2s a@3s b@4s a@6s]"
+	if got := testTakingTurns(newInstantClock(2)); got != want {
+		t.Errorf("Now() after wake ups = %v, want %v", got, want)
+	}
+}
+
+func Test_pacedClock(t *testing.T) {
+	want := "[b@2s a@3s b@4s a@6s]"
+	if got := testTakingTurns(newPacedClock(2, 1000.0)); got != want {
+		t.Errorf("Now() after wake ups = %v, want %v", got, want)
+	}
+}
+
+func Test_pacedClock_Await(t *testing.T) {
+	clock := newPacedClock(2, math.Inf(1))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go clock.run(ctx)
+
+	player := make(chan struct{})
+	woke := make(chan time.Duration)
+	go func() {
+		for i := 0; i < 3; i++ {
+			clock.Sleep(ctx, time.Second)
+		}
+		woke <- clock.Now()
+	}()
+	// The other participant keeps waking up while this one waits for the player.
+	clock.Await(func() {
+		if got := <-woke; got != 3*time.Second {
+			t.Errorf("Now() while awaiting = %v, want %v", got, 3*time.Second)
+		}
+		close(player)
+	})
+	<-player
+}
+
+func Test_animatedClock(t *testing.T) {
+	clock := newAnimatedClock(newInstantClock(1), 100.0)
+	ctx := context.Background()
+	slept := make(chan error)
+	go func() {
+		slept <- clock.Sleep(ctx, 10*time.Second)
+	}()
+	// 10 seconds take 100 milliseconds at 100 times real-time, the clock is somewhere in between.
+	time.Sleep(30 * time.Millisecond)
+	if got := clock.Now(); got <= 0 || got >= 10*time.Second {
+		t.Errorf("Now() while sleeping = %v, want between 0s and 10s", got)
+	}
+	if err := <-slept; err != nil {
+		t.Fatalf("Sleep() error = %v", err)
+	}
+	if got := clock.Now(); got != 10*time.Second {
+		t.Errorf("Now() after sleeping = %v, want %v", got, 10*time.Second)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ANSI colors of the markers on the timeline and the map, and of the results of a shot.
+const (
+	colorReset    = "\x1b[0m"
+	colorTank     = "\x1b[1;32m"    // bold green
+	colorTarget   = "\x1b[1;31m"    // bold red
+	colorMiss     = "\x1b[1;33m"    // bold yellow
+	colorHit      = "\x1b[1;33;41m" // bold yellow on red, the explosion
+	colorHitText  = "\x1b[1;31m"    // bold red
+	colorIncoming = "\x1b[35m"      // magenta
+	colorShort    = "\x1b[36m"      // cyan
+	colorLong     = "\x1b[33m"      // yellow
+)
+
+// colorModes are the values of the -color flag.
+var colorModes = []string{"auto", "always", "never"}
+
+// getColorEnabled decides whether to color the display. In the auto mode the display is colored on a
+// terminal, unless $NO_COLOR is set or the terminal is dumb.
+func getColorEnabled(mode string, noColor, dumb, terminal bool) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		return terminal && !noColor && !dumb, nil
+	}
+	return false, fmt.Errorf("unknown color mode %q: want one of %s", mode, strings.Join(colorModes, ", "))
+}
+
+// isTerminal reports whether f is a terminal rather than a file or a pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// paint colors text when the display is colored.
+func (g *Game) paint(color, text string) string {
+	if !g.color {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// paintTimeline colors the markers on the impact path, or on a row of the battlefield map: the tank,
+// the targets, where the shot landed and the enemy shells.
+func (g *Game) paintTimeline(line string) string {
+	if !g.color {
+		return line
+	}
+	var b strings.Builder
+	for i, c := range line {
+		color := ""
+		switch {
+		case c == '@' || (c == '/' && i == 0):
+			color = colorTank
+		case c == 'T' || (c >= '1' && c <= '9'):
+			color = colorTarget
+		case c == '\\' || c == 'o':
+			color = colorMiss
+		case c == '*':
+			color = colorHit
+		case c == 'x':
+			color = colorIncoming
+		}
+		if color == "" {
+			b.WriteRune(c)
+		} else {
+			b.WriteString(color + string(c) + colorReset)
+		}
+	}
+	return b.String()
+}
+
+// getVisibleText cuts text down to width characters on the screen, keeping its colors.
+func getVisibleText(text string, width int) string {
+	var b strings.Builder
+	visible, escape, colored := 0, false, false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\x1b':
+			escape, colored = true, true
+		case escape:
+			escape = c < '@' || c > '~' || c == '['
+		case visible == width:
+			if colored {
+				b.WriteString(colorReset)
+			}
+			return b.String()
+		default:
+			visible++
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
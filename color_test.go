@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_getColorEnabled(t *testing.T) {
+	type args struct {
+		mode     string
+		noColor  bool
+		dumb     bool
+		terminal bool
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    bool
+		wantErr bool
+	}{
+		{name: "Auto on a terminal", args: args{"auto", false, false, true}, want: true},
+		{name: "Auto on a pipe", args: args{"auto", false, false, false}, want: false},
+		{name: "Auto with NO_COLOR", args: args{"auto", true, false, true}, want: false},
+		{name: "Auto on a dumb terminal", args: args{"auto", false, true, true}, want: false},
+		{name: "Always overrides NO_COLOR", args: args{"always", true, true, false}, want: true},
+		{name: "Never", args: args{"never", false, false, true}, want: false},
+		{name: "Unknown mode", args: args{"blue", false, false, true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getColorEnabled(tt.args.mode, tt.args.noColor, tt.args.dumb, tt.args.terminal)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getColorEnabled() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getColorEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGame_paintTimeline(t *testing.T) {
+	tests := []struct {
+		name  string
+		color bool
+		line  string
+		want  string
+	}{
+		{
+			name: "Without color",
+			line: "/---\\--T-|",
+			want: "/---\\--T-|",
+		},
+		{
+			name:  "Tank, miss and target",
+			color: true,
+			line:  "/---\\--T-|",
+			want:  colorTank + "/" + colorReset + "---" + colorMiss + "\\" + colorReset + "--" + colorTarget + "T" + colorReset + "-|",
+		},
+		{
+			name:  "Hit and incoming shell",
+			color: true,
+			line:  "-*-x-",
+			want:  "-" + colorHit + "*" + colorReset + "-" + colorIncoming + "x" + colorReset + "-",
+		},
+		{
+			name:  "Map row",
+			color: true,
+			line:  "| .  @  2 |",
+			want:  "| .  " + colorTank + "@" + colorReset + "  " + colorTarget + "2" + colorReset + " |",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Game{color: tt.color}
+			if got := g.paintTimeline(tt.line); got != tt.want {
+				t.Errorf("paintTimeline() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getVisibleText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{name: "Short", text: "abc", width: 5, want: "abc"},
+		{name: "Cut", text: "abcdef", width: 3, want: "abc"},
+		{name: "Colors are not counted", text: colorTank + "ab" + colorReset + "cd", width: 4, want: colorTank + "ab" + colorReset + "cd"},
+		{name: "Cut in a color is reset", text: colorTank + "abcd" + colorReset, width: 2, want: colorTank + "ab" + colorReset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getVisibleText(tt.text, tt.width); got != tt.want {
+				t.Errorf("getVisibleText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGame_printImpactResults_color(t *testing.T) {
+	tests := []struct {
+		name      string
+		shotRange float64
+		want      string
+	}{
+		{name: "Hit", shotRange: 505.0, want: colorHitText + "Direct hit"},
+		{name: "Undershot", shotRange: 300.0, want: colorShort + "<< Undershot"},
+		{name: "Overshot", shotRange: 700.0, want: colorLong + ">> Overshot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			g := &Game{config: Config{DeathRadius: impactRadius}, out: &out, maxRange: 1000.0, color: true}
+			g.targets = []target{{distance: 500.0}}
+			g.setTimelineWidth(defaultTimelineWidth)
+			shot := shotResult{Range: tt.shotRange, Target: 1, Delta: 500.0 - tt.shotRange}
+			g.printImpactResults(shot, 1)
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("printImpactResults() printed %q, want it to contain %q", out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDifficulty      = "normal"
+	maxProjectileLimitVmps = 2000 // meters/sec, faster than any gun
+	maxTargetLimitVkph     = 200  // kilometers/hour, faster than any tank
+	maxTargets             = 9    // a digit marks each target on the impact timeline
+)
+
+// difficultyNames lists the presets from the easiest to the hardest.
+var difficultyNames = []string{"easy", "normal", "hard", "insane"}
+
+// difficulties change the default configuration into a preset.
+var difficulties = map[string]func(config *Config){
+	"easy": func(config *Config) {
+		config.MinProjectileVmps = 400.0
+		config.MinTargetVkph, config.MaxTargetVkph = 0.0, 30.0
+		config.MinStartRange = 0.5
+		config.DeathRadius = 50.0
+	},
+	"normal": func(config *Config) {},
+	"hard": func(config *Config) {
+		config.MaxProjectileVmps = 500.0
+		config.MinTargetVkph, config.MaxTargetVkph = 30.0, 90.0
+		config.MaxStartRange = 0.8
+		config.DeathRadius = 10.0
+		config.TargetModeAuto = true
+	},
+	"insane": func(config *Config) {
+		config.MinProjectileVmps, config.MaxProjectileVmps = 250.0, 400.0
+		config.MinTargetVkph, config.MaxTargetVkph = 60.0, 120.0
+		config.MinStartRange, config.MaxStartRange = 0.2, 0.6
+		config.DeathRadius = 5.0
+		config.TargetModeAuto = true
+		config.Drag = true
+		config.WindGust = 20.0
+	},
+}
+
+// defaultConfig returns the configuration of a game without any options.
+func defaultConfig() Config {
+	return Config{
+		Difficulty:        defaultDifficulty,
+		Strategy:          defaultStrategy,
+		DeathRadius:       impactRadius,
+		DragCoefficient:   defaultDragCoefficient,
+		ProjectileMass:    defaultProjectileMass,
+		Caliber:           defaultCaliber,
+		MinProjectileVmps: minProjectileVmps,
+		MaxProjectileVmps: maxProjectileVmps,
+		MinTargetVkph:     minTargetVkph,
+		MaxTargetVkph:     maxTargetVkph,
+		MinStartRange:     minStartRange,
+		MaxStartRange:     maxStartRange,
+		Targets:           1,
+		TargetBehavior:    defaultBehavior,
+		HitPoints:         defaultHitPoints,
+		BotTimeout:        defaultBotTimeout,
+	}
+}
+
+// validate reports the first option that doesn't make sense.
+func (c Config) validate() error {
+	switch {
+	case c.MinProjectileVmps <= 0.0 || c.MaxProjectileVmps < c.MinProjectileVmps:
+		return fmt.Errorf("invalid projectile velocity from %g to %g meters/sec: want 0 < min <= max", c.MinProjectileVmps, c.MaxProjectileVmps)
+	case c.MinTargetVkph < 0.0 || c.MaxTargetVkph < c.MinTargetVkph:
+		return fmt.Errorf("invalid target velocity from %g to %g kilometers/hour: want 0 <= min <= max", c.MinTargetVkph, c.MaxTargetVkph)
+	case c.MinStartRange <= 0.0 || c.MaxStartRange < c.MinStartRange || c.MaxStartRange > 1.0:
+		return fmt.Errorf("invalid start range from %g to %g of the max range: want 0 < min <= max <= 1", c.MinStartRange, c.MaxStartRange)
+	case c.MinStartMeters < 0.0 || c.MaxStartMeters < 0.0 || (c.MaxStartMeters > 0.0 && c.MaxStartMeters < c.MinStartMeters):
+		return fmt.Errorf("invalid start range from %g to %g meters: want 0 <= min <= max, 0 = not set", c.MinStartMeters, c.MaxStartMeters)
+	case c.MaxProjectileVmps > maxProjectileLimitVmps:
+		return fmt.Errorf("invalid projectile velocity %g meters/sec: want at most %g", c.MaxProjectileVmps, float64(maxProjectileLimitVmps))
+	case c.MaxTargetVkph > maxTargetLimitVkph:
+		return fmt.Errorf("invalid target velocity %g kilometers/hour: want at most %g", c.MaxTargetVkph, float64(maxTargetLimitVkph))
+	case c.Targets < 0 || c.Targets > maxTargets:
+		return fmt.Errorf("invalid number of targets %d: want 1 to %d, 0 = 1", c.Targets, maxTargets)
+	case c.ReturnFire && c.HitPoints < 1:
+		return fmt.Errorf("invalid hit points %d: want at least 1", c.HitPoints)
+	case c.BotTimeout < 0.0:
+		return fmt.Errorf("invalid bot timeout %g seconds: want more than 0", c.BotTimeout)
+	case c.DeathRadius <= 0.0:
+		return fmt.Errorf("invalid detonation radius %g meters: want more than 0", c.DeathRadius)
+	}
+	return nil
+}
+
+func getDifficultyNames() string {
+	return strings.Join(difficultyNames, ", ")
+}
+
+// exactValue is a flag that sets both ends of a range to the same value.
+type exactValue struct {
+	min, max *float64
+	set      bool
+}
+
+func (v *exactValue) String() string {
+	if v == nil || !v.set {
+		return ""
+	}
+	return strconv.FormatFloat(*v.min, 'g', -1, 64)
+}
+
+func (v *exactValue) Set(s string) error {
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*v.min, *v.max = value, value
+	v.set = true
+	return nil
+}
+
+// exactFlags are the exactValue flags, with the min and max flags that they conflict with.
+var exactFlags = map[string][2]string{
+	"velocity":        {"min-velocity", "max-velocity"},
+	"target-velocity": {"min-target-velocity", "max-target-velocity"},
+	"range":           {"min-range", "max-range"},
+}
+
+// configFlags layers the configuration of a game: the defaults, the difficulty preset, the config
+// file, and then the flags that were set on the command line. Without a seed from the file or the
+// flags, the game is seeded from the current time.
+type configFlags struct {
+	fs     *flag.FlagSet
+	config Config
+	file   string
+}
+
+func newConfigFlags(fs *flag.FlagSet) *configFlags {
+	f := &configFlags{fs: fs, config: defaultConfig()}
+	addGameFlags(fs, &f.config)
+	fs.StringVar(&f.file, "config", "", "JSON Config File with Game Options, the other flags take precedence")
+	return f
+}
+
+// rejectFlags returns an error for the first of names that was set on fs, for a command that can't use
+// every option. why tells the player what to do instead.
+func rejectFlags(fs *flag.FlagSet, why string, names ...string) error {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for _, name := range names {
+		if set[name] {
+			return fmt.Errorf("invalid flag -%s: %s", name, why)
+		}
+	}
+	return nil
+}
+
+// resolve returns the configuration once the flags are parsed.
+func (f *configFlags) resolve() (Config, error) {
+	set := map[string]bool{}
+	var names []string
+	f.fs.Visit(func(fl *flag.Flag) {
+		set[fl.Name] = true
+		names = append(names, fl.Name)
+	})
+	for exact, minMax := range exactFlags {
+		if set[exact] && (set[minMax[0]] || set[minMax[1]]) {
+			return Config{}, fmt.Errorf("invalid flags: -%s can't be used with -%s or -%s", exact, minMax[0], minMax[1])
+		}
+	}
+
+	var file []byte
+	if f.file != "" {
+		var err error
+		if file, err = os.ReadFile(f.file); err != nil {
+			return Config{}, err
+		}
+	}
+	difficulty := defaultDifficulty
+	if set["difficulty"] {
+		difficulty = f.config.Difficulty
+	} else if file != nil {
+		var preset struct {
+			Difficulty string `json:"difficulty"`
+		}
+		if err := json.Unmarshal(file, &preset); err != nil {
+			return Config{}, fmt.Errorf("invalid config file %s: %v", f.file, err)
+		}
+		if preset.Difficulty != "" {
+			difficulty = preset.Difficulty
+		}
+	}
+	config, err := newDifficultyConfig(difficulty)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if file != nil {
+		decoder := json.NewDecoder(bytes.NewReader(file))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			return Config{}, fmt.Errorf("invalid config file %s: %v", f.file, err)
+		}
+		config.Difficulty = difficulty
+	}
+	seeded := set["seed"]
+	if !seeded && file != nil {
+		var fileSeed struct {
+			Seed *int64 `json:"seed"`
+		}
+		if err := json.Unmarshal(file, &fileSeed); err != nil {
+			return Config{}, fmt.Errorf("invalid config file %s: %v", f.file, err)
+		}
+		seeded = fileSeed.Seed != nil
+	}
+	if !seeded {
+		config.Seed = time.Now().UnixNano()
+	}
+
+	// Apply the flags from the command line again, on top of the preset and the file.
+	flags := flag.NewFlagSet(f.fs.Name(), flag.ContinueOnError)
+	addGameFlags(flags, &config)
+	for _, name := range names {
+		if fl := flags.Lookup(name); fl != nil {
+			if err := fl.Value.Set(f.fs.Lookup(name).Value.String()); err != nil {
+				return Config{}, err
+			}
+		}
+	}
+	return config, config.validate()
+}
+
+// newDifficultyConfig returns the default configuration changed into the difficulty preset.
+func newDifficultyConfig(difficulty string) (Config, error) {
+	preset, ok := difficulties[difficulty]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown difficulty %q: want one of %s", difficulty, getDifficultyNames())
+	}
+	config := defaultConfig()
+	config.Difficulty = difficulty
+	preset(&config)
+	return config, nil
+}
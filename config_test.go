@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_configFlags_resolve(t *testing.T) {
+	dir := t.TempDir()
+	file := func(content string) string {
+		path := filepath.Join(dir, "config.json")
+		os.WriteFile(path, []byte(content), 0644)
+		return path
+	}
+
+	tests := []struct {
+		name           string
+		args           func() []string
+		want           func(config *Config)
+		wantDifficulty string
+		wantSeed       *int64 // nil = seeded from the current time
+		wantErr        bool
+	}{
+		{
+			name: "Defaults",
+			args: func() []string { return nil },
+			want: func(config *Config) {},
+		},
+		{
+			name:           "Difficulty Preset",
+			args:           func() []string { return []string{"-difficulty", "hard"} },
+			want:           difficulties["hard"],
+			wantDifficulty: "hard",
+		},
+		{
+			name: "Flags on top of the Preset",
+			args: func() []string { return []string{"-difficulty", "hard", "-d", "15", "-m=false"} },
+			want: func(config *Config) {
+				difficulties["hard"](config)
+				config.DeathRadius = 15.0
+				config.TargetModeAuto = false
+			},
+			wantDifficulty: "hard",
+		},
+		{
+			name: "File with a Preset",
+			args: func() []string {
+				return []string{"-config", file(`{"difficulty": "easy", "max_target_vkph": 45, "shoot_mode_auto": true}`)}
+			},
+			want: func(config *Config) {
+				difficulties["easy"](config)
+				config.MaxTargetVkph = 45.0
+				config.ShootModeAuto = true
+			},
+			wantDifficulty: "easy",
+		},
+		{
+			name: "Flags on top of the File",
+			args: func() []string {
+				return []string{"-a=false", "-config", file(`{"shoot_mode_auto": true, "min_start_range": 0.5, "death_radius": 30}`), "-d", "25"}
+			},
+			want: func(config *Config) {
+				config.MinStartRange = 0.5
+				config.DeathRadius = 25.0
+			},
+		},
+		{
+			name: "Difficulty Flag picks the Preset under the File",
+			args: func() []string {
+				return []string{"-difficulty", "insane", "-config", file(`{"difficulty": "easy", "death_radius": 30}`)}
+			},
+			want: func(config *Config) {
+				difficulties["insane"](config)
+				config.DeathRadius = 30.0
+			},
+			wantDifficulty: "insane",
+		},
+		{
+			name: "Exact Values",
+			args: func() []string { return []string{"-velocity", "450", "-target-velocity", "0", "-range", "5000"} },
+			want: func(config *Config) {
+				config.MinProjectileVmps, config.MaxProjectileVmps = 450.0, 450.0
+				config.MinTargetVkph, config.MaxTargetVkph = 0.0, 0.0
+				config.MinStartMeters, config.MaxStartMeters = 5000.0, 5000.0
+			},
+		},
+		{
+			name: "Exact Value on top of the File",
+			args: func() []string {
+				return []string{"-config", file(`{"min_target_vkph": 10, "max_target_vkph": 20}`), "-target-velocity", "40"}
+			},
+			want: func(config *Config) {
+				config.MinTargetVkph, config.MaxTargetVkph = 40.0, 40.0
+			},
+		},
+		{
+			name: "Custom Ranges",
+			args: func() []string {
+				return []string{"-min-velocity", "350", "-max-target-velocity", "20", "-max-range", "8000"}
+			},
+			want: func(config *Config) {
+				config.MinProjectileVmps = 350.0
+				config.MaxTargetVkph = 20.0
+				config.MaxStartMeters = 8000.0
+			},
+		},
+		{
+			name:     "Seed 0",
+			args:     func() []string { return []string{"-seed", "0"} },
+			want:     func(config *Config) {},
+			wantSeed: new(int64),
+		},
+		{
+			name:     "Seed 0 in the File",
+			args:     func() []string { return []string{"-config", file(`{"seed": 0}`)} },
+			want:     func(config *Config) {},
+			wantSeed: new(int64),
+		},
+		{
+			name:    "Exact Value with a Custom Range",
+			args:    func() []string { return []string{"-velocity", "450", "-max-velocity", "500"} },
+			wantErr: true,
+		},
+		{
+			name:    "Projectile faster than any Gun",
+			args:    func() []string { return []string{"-velocity", "5000"} },
+			wantErr: true,
+		},
+		{
+			name:    "Unknown Difficulty",
+			args:    func() []string { return []string{"-difficulty", "nightmare"} },
+			wantErr: true,
+		},
+		{
+			name:    "Unknown Option in the File",
+			args:    func() []string { return []string{"-config", file(`{"death_radios": 30}`)} },
+			wantErr: true,
+		},
+		{
+			name:    "Invalid Range in the File",
+			args:    func() []string { return []string{"-config", file(`{"min_projectile_vmps": 700}`)} },
+			wantErr: true,
+		},
+		{
+			name:    "Invalid Seed in the File",
+			args:    func() []string { return []string{"-config", file(`{"seed": "1"}`)} },
+			wantErr: true,
+		},
+		{
+			name:    "Missing File",
+			args:    func() []string { return []string{"-config", filepath.Join(dir, "missing.json")} },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("tank", flag.ContinueOnError)
+			configFlags := newConfigFlags(fs)
+			if err := fs.Parse(tt.args()); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := configFlags.resolve()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			want := defaultConfig()
+			tt.want(&want)
+			if tt.wantDifficulty != "" {
+				want.Difficulty = tt.wantDifficulty
+			}
+			if tt.wantSeed != nil {
+				want.Seed = *tt.wantSeed
+			} else if got.Seed == 0 {
+				t.Errorf("resolve() seed = 0, want a seed from the current time")
+			} else {
+				want.Seed = got.Seed
+			}
+			if got != want {
+				t.Errorf("resolve() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestConfig_validate(t *testing.T) {
+	for _, name := range difficultyNames {
+		config, err := newDifficultyConfig(name)
+		if err != nil {
+			t.Fatalf("newDifficultyConfig(%q) error = %v", name, err)
+		}
+		if err := config.validate(); err != nil {
+			t.Errorf("validate() of %s = %v, want no error", name, err)
+		}
+	}
+
+	// 0 leaves these options at their defaults.
+	for _, change := range []func(config *Config){
+		func(config *Config) { config.Targets = 0 },
+		func(config *Config) { config.MinStartMeters, config.MaxStartMeters = 0.0, 5000.0 },
+		func(config *Config) { config.MinStartMeters, config.MaxStartMeters = 5000.0, 0.0 },
+	} {
+		config := defaultConfig()
+		change(&config)
+		if err := config.validate(); err != nil {
+			t.Errorf("validate() of %+v = %v, want no error", config, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(config *Config)
+	}{
+		{"No Projectile Velocity", func(config *Config) { config.MinProjectileVmps = 0.0 }},
+		{"Target Velocity Range Upside Down", func(config *Config) { config.MinTargetVkph = 70.0 }},
+		{"Start Range beyond the Max Range", func(config *Config) { config.MaxStartRange = 1.5 }},
+		{"No Detonation Radius", func(config *Config) { config.DeathRadius = 0.0 }},
+		{"Negative Start Meters", func(config *Config) { config.MinStartMeters = -1.0 }},
+		{"Start Meters Upside Down", func(config *Config) { config.MinStartMeters, config.MaxStartMeters = 8000.0, 5000.0 }},
+		{"Negative Targets", func(config *Config) { config.Targets = -1 }},
+		{"Too many Targets", func(config *Config) { config.Targets = maxTargets + 1 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := defaultConfig()
+			tt.change(&config)
+			if err := config.validate(); err == nil {
+				t.Errorf("validate() = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// duel is the hot-seat game for two players, with a tank at each end of the impact path. The players
+// take turns at the same prompt and the first hit wins. Player 1 is on the left, and the wind blows
+// the way that it does for the tank in a game, so a tail wind for player 1 is a head wind for player 2.
+// The shots are the shots of the game, and the target of the game is the other tank.
+type duel struct {
+	g        *Game
+	distance float64 // meters between the tanks
+	player   int     // 0 or 1, whose turn it is
+	shots    [2]int
+	winner   int // 1 or 2, 0 = nobody won
+}
+
+// duelFlags are the Game Options for the targets, the terrain and the Auto Shot Mode, which a duel
+// doesn't have.
+var duelFlags = []string{
+	"a", "m", "speed", "strategy", "bot-timeout",
+	"target-velocity", "min-target-velocity", "max-target-velocity",
+	"targets", "behavior", "return-fire", "hp", "2d", "elevation", "terrain", "tui",
+}
+
+// runDuel is the duel command.
+func runDuel(args []string, input io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("duel", flag.ExitOnError)
+	configFlags := newConfigFlags(fs)
+	var output outputOptions
+	addOutputFlags(fs, &output)
+	fs.Parse(args)
+	if err := rejectFlags(fs, "can't be used in a duel", duelFlags...); err != nil {
+		return err
+	}
+	if err := output.validate(); err != nil {
+		return err
+	}
+	config, err := configFlags.resolve()
+	if err != nil {
+		return err
+	}
+	var events io.Writer
+	if output.JSON {
+		out, events = io.Discard, out
+	}
+	if output.Events != "" {
+		f, err := os.Create(output.Events)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		events = f
+	}
+	d, err := newDuel(config, input, out)
+	if err != nil {
+		return err
+	}
+	if events != nil {
+		d.g.streamEvents(events)
+	}
+	sizeTimelineForStdout(d.g, output.Width)
+	d.g.color = output.colorEnabled()
+	d.run(context.Background())
+	if output.Record != "" {
+		return saveRecording(output.Record, d.g.recording())
+	}
+	return nil
+}
+
+// newDuel draws the duel like a game, the tanks start as far apart as a target would. The options
+// that a duel doesn't have are turned off, in case a difficulty preset or a config file sets them.
+func newDuel(config Config, input io.Reader, out io.Writer) (*duel, error) {
+	// Neither tank moves, and both of them stand on level ground.
+	config.ShootModeAuto, config.TargetModeAuto = false, false
+	config.MinTargetVkph, config.MaxTargetVkph = 0.0, 0.0
+	config.Targets, config.TargetBehavior = 1, defaultBehavior
+	config.ReturnFire, config.TwoD = false, false
+	config.Elevation, config.Terrain = 0.0, 0.0
+	g, err := NewGame(config, input, out)
+	if err != nil {
+		return nil, err
+	}
+	d := &duel{g: g, distance: g.targets[0].distance}
+	g.duel = d
+	return d, nil
+}
+
+// run plays the duel until a player hits the other tank or surrenders, like the battle manager plays
+// a game. The shots are numbered through the duel, for the events and the recording.
+func (d *duel) run(ctx context.Context) {
+	g := d.g
+	if g.clock == nil {
+		g.clock = g.newClock()
+	}
+	d.printConfiguration()
+	if g.config.PrintShotProfile {
+		g.displayShotProfile()
+	}
+	if g.replay == nil && g.lines == nil {
+		g.lines = readLines(g.input)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if c, ok := g.clock.(*pacedClock); ok {
+		go c.run(ctx)
+	}
+	for shotCount := 0; ; d.player = 1 - d.player {
+		g.mu.Lock()
+		g.ballistics.wind = d.getPlayerWindKph() * (metersPerKilometer / secondsPerHour)
+		g.mu.Unlock()
+		d.printHeader(shotCount + 1)
+		var shotAngle float64
+		if g.replay != nil {
+			shotAngle = g.nextReplayShot(ctx, shotCount).Angle
+		} else {
+			g.emit("awaiting_shot", &awaitingShotEvent{Shot: shotCount + 1, Wind: g.ballistics.wind})
+			shotAngle = getNextShotAngle(ctx, g.lines, g.out, g.maxAngle())
+		}
+		if shotAngle == 0.0 {
+			if ctx.Err() == nil {
+				fmt.Fprintf(g.out, "Player %d surrenders!\n", d.player+1)
+				d.winner = 2 - d.player
+				g.mu.Lock()
+				g.outcome = outcomeQuit
+				g.emit("quit", &quitEvent{Shots: shotCount})
+				g.mu.Unlock()
+			}
+			break
+		}
+		shotCount++
+		d.shots[d.player]++
+		hit := d.takeShot(ctx, shotCount, shotAngle)
+		if ctx.Err() != nil {
+			break
+		}
+		if hit {
+			d.winner = d.player + 1
+			break
+		}
+		if g.config.MaxShots > 0 && d.shots[1] >= g.config.MaxShots {
+			fmt.Fprintf(g.out, "Both players are out of ammunition after %d shots!\n", g.config.MaxShots)
+			g.mu.Lock()
+			g.outcome = outcomeOutOfShots
+			g.emit("out_of_ammo", &outOfAmmoEvent{Shots: shotCount})
+			g.mu.Unlock()
+			break
+		}
+		g.driftWind()
+	}
+	d.printSummary()
+}
+
+func (d *duel) printConfiguration() {
+	g := d.g
+	fmt.Fprintln(g.out, "Hot-Seat Duel: Player 1 on the left, Player 2 on the right")
+	fmt.Fprintf(g.out, "Seed: %d\n", g.seed)
+	fmt.Fprintf(g.out, "Units: %s\n", englishOrMetric[g.config.EnglishUnits])
+	fmt.Fprintf(g.out, "Detonation Radius = %s\n", g.getDisplayText(g.config.DeathRadius))
+	fmt.Fprintf(g.out, "Projectile Velocity: %s\n", getRandomRangeText(g.config.MinProjectileVmps, g.config.MaxProjectileVmps, func(v float64) string {
+		return g.getDisplayText(v) + "/sec"
+	}))
+	minStart, maxStart := g.getStartRange()
+	fmt.Fprintf(g.out, "Distance between the Tanks: %s\n", getRandomRangeText(minStart, maxStart, g.getDisplayText))
+	if g.config.HighAngle {
+		fmt.Fprintf(g.out, "High-Angle Fire: up to %3.1f degrees\n", maxHighShotAngle)
+	}
+	if g.ballistics.drag != nil {
+		fmt.Fprintf(g.out, "Air Drag: Coefficient = %.2f, Mass = %.1f kg, Caliber = %.0f mm\n", g.config.DragCoefficient, g.config.ProjectileMass, g.config.Caliber)
+	}
+	g.emitGameStart()
+}
+
+// getPlayerWindKph returns the wind as the player whose turn it is sees it, + = tail wind, - = head wind.
+func (d *duel) getPlayerWindKph() float64 {
+	if d.player == 1 {
+		return -d.g.windKph
+	}
+	return d.g.windKph
+}
+
+// getOpponentName names the tank that the player whose turn it is shoots at.
+func (d *duel) getOpponentName() string {
+	return fmt.Sprintf("Player %d", 2-d.player)
+}
+
+func (d *duel) printHeader(shotCount int) {
+	g := d.g
+	englishUnits := g.config.EnglishUnits
+	fmt.Fprintln(g.out, "==================================")
+	fmt.Fprintf(g.out, "Player %d, shot #%d\n", d.player+1, shotCount)
+	fmt.Fprintf(g.out, "Projectile Velocity  = %s/sec\n", g.getDisplayText(g.projectileVmps))
+	fmt.Fprintf(g.out, "Max Projectile Range = %s\n", g.getDisplayText(g.maxRange))
+	if windKph := d.getPlayerWindKph(); g.config.Wind != 0.0 || g.config.WindGust > 0.0 {
+		fmt.Fprintf(g.out, "Wind Velocity        = %3.1f %s/hour (%s)\n", getMilesOrKilometers(math.Abs(windKph)*metersPerKilometer, englishUnits), milesOrKilometers[englishUnits], getWindText(windKph))
+	}
+	fmt.Fprintf(g.out, "Enemy Tank Range     = %s\n", g.getDisplayText(d.distance))
+	fmt.Fprintln(g.out, "----------------------------------")
+}
+
+// takeShot fires the shot of the player whose turn it is through the game, and reports whether it hit
+// the other tank. Nothing moves in a duel, so the shot lands at once.
+func (d *duel) takeShot(ctx context.Context, shotCount int, shotAngle float64) bool {
+	g := d.g
+	firedAt := g.clock.Now().Seconds()
+	g.mu.Lock()
+	g.recordedShots = append(g.recordedShots, recordedShot{Angle: shotAngle, FiredAt: firedAt})
+	g.mu.Unlock()
+	shot := g.takeShot(ctx, shotCount, shotAngle, 0.0)
+	if ctx.Err() != nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.printImpactResults(shot, shotCount)
+	shot.FiredAt = firedAt
+	g.shots = append(g.shots, shot)
+	return g.outcome == outcomeHit
+}
+
+// printTimeline draws the shot of the player whose turn it is on the timeline between the tanks. It is
+// drawn from the left like a shot in a game, and mirrored for player 2.
+func (d *duel) printTimeline(shotRange float64, hit bool) {
+	g := d.g
+	impactPath, ruler := g.getRuler(d.distance)
+	last := g.width - 1
+	// The other tank is at the end of the timeline, a shot beyond it is drawn next to it.
+	shotIndex := int(math.Max(2, math.Min(float64(last), float64(int(shotRange/d.distance*float64(last))+1))))
+	curFlightPath := g.flightPath[:shotIndex-2] + "\\"
+	mark, markIndex := byte('\\'), shotIndex-1
+	if hit {
+		curFlightPath = g.flightPath[:last-1] + "\\"
+		mark, markIndex = '*', last
+	}
+	if d.player == 1 {
+		// Only the shot is mirrored, the ruler stays where it is.
+		curFlightPath = strings.TrimRight(getMirrorText(fmt.Sprintf("%-*s", g.width, curFlightPath)), " ")
+		mark, markIndex = getMirrorText(string(mark))[0], last-markIndex
+	}
+	curImpactPath := []byte(impactPath[:last] + "\\")
+	curImpactPath[markIndex] = mark
+	// Both ends are tanks, so the markers are painted by where they are rather than what they look like.
+	var impact strings.Builder
+	for i, c := range curImpactPath {
+		switch {
+		case i == markIndex && hit:
+			impact.WriteString(g.paint(colorHit, string(c)))
+		case i == markIndex:
+			impact.WriteString(g.paint(colorMiss, string(c)))
+		case i == 0 || i == last:
+			impact.WriteString(g.paint(colorTank, string(c)))
+		default:
+			impact.WriteByte(c)
+		}
+	}
+	fmt.Fprintln(g.out, "")
+	fmt.Fprintln(g.out, curFlightPath)
+	fmt.Fprintln(g.out, impact.String())
+	fmt.Fprintln(g.out, ruler)
+	fmt.Fprintf(g.out, "%-*s%s\n", g.width-len("Player 2"), "Player 1", "Player 2")
+	fmt.Fprintln(g.out, "")
+}
+
+// getMirrorText flips a timeline from left to right.
+func getMirrorText(text string) string {
+	mirror := []byte(text)
+	for i, j := 0, len(mirror)-1; i <= j; i, j = i+1, j-1 {
+		mirror[i], mirror[j] = mirror[j], mirror[i]
+	}
+	for i, c := range mirror {
+		switch c {
+		case '/':
+			mirror[i] = '\\'
+		case '\\':
+			mirror[i] = '/'
+		}
+	}
+	return string(mirror)
+}
+
+func (d *duel) printSummary() {
+	g := d.g
+	fmt.Fprintln(g.out, "==================================")
+	if d.winner > 0 {
+		fmt.Fprintf(g.out, "Player %d wins the duel!\n", d.winner)
+	} else {
+		fmt.Fprintln(g.out, "The duel is a draw.")
+	}
+	for player, shots := range d.shots {
+		fmt.Fprintf(g.out, "Player %d             = %d shots\n", player+1, shots)
+	}
+	fmt.Fprintln(g.out, "==================================")
+}
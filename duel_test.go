@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDuel_run(t *testing.T) {
+	hit := fmt.Sprintf("%v\n", xAngle(5000.0, 400.0))
+	tests := []struct {
+		name       string
+		input      string
+		maxShots   int
+		wantWinner int
+		wantShots  [2]int
+	}{
+		{
+			name:       "Player 1 hits first",
+			input:      hit,
+			wantWinner: 1,
+			wantShots:  [2]int{1, 0},
+		},
+		{
+			name:       "Player 2 hits after a miss",
+			input:      "10\n" + hit,
+			wantWinner: 2,
+			wantShots:  [2]int{1, 1},
+		},
+		{
+			name:       "Player 1 surrenders",
+			input:      "10\n20\n0\n",
+			wantWinner: 2,
+			wantShots:  [2]int{1, 1},
+		},
+		{
+			name:       "Out of ammunition",
+			input:      "10\n20\n30\n40\n",
+			maxShots:   2,
+			wantWinner: 0,
+			wantShots:  [2]int{2, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := withDefaults(Config{DeathRadius: impactRadius, Seed: 1, MaxShots: tt.maxShots})
+			config.MinProjectileVmps, config.MaxProjectileVmps = 400.0, 400.0
+			config.MinStartMeters, config.MaxStartMeters = 5000.0, 5000.0
+			d, err := newDuel(config, strings.NewReader(tt.input), io.Discard)
+			if err != nil {
+				t.Fatalf("newDuel() error = %v", err)
+			}
+			d.run(context.Background())
+			if d.winner != tt.wantWinner || d.shots != tt.wantShots {
+				t.Errorf("run() winner = %d, shots = %v, want %d, %v", d.winner, d.shots, tt.wantWinner, tt.wantShots)
+			}
+		})
+	}
+}
+
+func Test_getMirrorText(t *testing.T) {
+	if got, want := getMirrorText(" /~~\\-+"), "+-/~~\\ "; got != want {
+		t.Errorf("getMirrorText() = %q, want %q", got, want)
+	}
+}
+
+func Test_runDuel(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "Shot Options", args: []string{"-seed", "1", "-wind", "10", "-drag", "-color", "never"}},
+		{name: "Targets", args: []string{"-targets", "2"}, wantErr: true},
+		{name: "Auto Shot Mode", args: []string{"-a"}, wantErr: true},
+		{name: "Target Velocity", args: []string{"-min-target-velocity", "5"}, wantErr: true},
+		{name: "Terrain", args: []string{"-terrain", "50"}, wantErr: true},
+		{name: "Full Screen", args: []string{"-tui"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := runDuel(tt.args, strings.NewReader("0\n"), io.Discard); (err != nil) != tt.wantErr {
+				t.Errorf("runDuel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDuel_replay(t *testing.T) {
+	config := withDefaults(Config{DeathRadius: impactRadius, Seed: 1, WindGust: 20.0})
+	config.MinProjectileVmps, config.MaxProjectileVmps = 400.0, 400.0
+	config.MinStartMeters, config.MaxStartMeters = 5000.0, 5000.0
+	var out, events strings.Builder
+	d, err := newDuel(config, strings.NewReader("10\n20\n30\n0\n"), &out)
+	if err != nil {
+		t.Fatalf("newDuel() error = %v", err)
+	}
+	d.g.streamEvents(&events)
+	d.run(context.Background())
+	rec := d.g.recording()
+	if !rec.Duel || len(rec.Shots) != 3 || rec.Outcome != outcomeQuit || d.winner != 1 {
+		t.Fatalf("recording() = duel %v, %d shots, %q, winner %d, want a duel of 3 shots that player 1 won when player 2 quit", rec.Duel, len(rec.Shots), rec.Outcome, d.winner)
+	}
+	// The shots are numbered through the duel, and the shot of player 2 is measured against player 1.
+	for _, want := range []string{`"type":"shot_fired","time":0,"shot":1`, `"type":"shot_impact"`, `"shot":3`, `"type":"quit"`} {
+		if !strings.Contains(events.String(), want) {
+			t.Errorf("run() events = %q, want them to contain %q", events.String(), want)
+		}
+	}
+	if !strings.Contains(out.String(), "Overshot Player 1") && !strings.Contains(out.String(), "Undershot Player 1") {
+		t.Errorf("run() output = %q, want the shot of player 2 measured against player 1", out.String())
+	}
+
+	var replayed strings.Builder
+	g, err := replayRecording(context.Background(), rec, math.Inf(1), false, strings.NewReader(""), &replayed)
+	if err != nil {
+		t.Fatalf("replayRecording() error = %v", err)
+	}
+	if g.duel == nil || g.duel.winner != 1 || g.duel.shots != d.shots {
+		t.Fatalf("replayRecording() = %+v, want the duel again", g.duel)
+	}
+	// The gusts blow the same way, so the shots go as far as they did.
+	shotLines := func(text string) (lines []string) {
+		for _, line := range strings.Split(text, "\n") {
+			if strings.HasPrefix(line, "Shot #") {
+				lines = append(lines, line)
+			}
+		}
+		return lines
+	}
+	if got, want := shotLines(replayed.String()), shotLines(out.String()); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("replayRecording() shots =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
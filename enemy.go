@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	defaultHitPoints   = 3
+	enemyReloadSeconds = 20.0  // seconds between the shots of a target
+	enemySpread        = 0.05  // standard deviation of the first miss, as a fraction of the range
+	enemySpreadFactor  = 0.75  // every shot is this much more accurate than the last
+	minEnemySpread     = 0.002 // the best that a target can shoot
+)
+
+// enemyGun is the gun of a target that fires back at the tank. It brackets the tank like a gunner
+// would, so every shot lands closer than the last on average.
+type enemyGun struct {
+	rand     *rand.Rand
+	vmps     float64 // projectile velocity
+	spread   float64 // standard deviation of the next miss, as a fraction of the range
+	nextShot float64 // simulated seconds since the start of the game
+	shells   []enemyShell
+}
+
+// enemyShell is a shot of an enemyGun on its way to the tank.
+type enemyShell struct {
+	landsAt float64 // simulated seconds since the start of the game
+	miss    float64 // meters short (+) or long (-) of the tank
+}
+
+// newEnemyGun draws the projectile velocity of the gun from the same range as the tank, and the time
+// of its first shot.
+func newEnemyGun(r *rand.Rand, minVmps, maxVmps float64) *enemyGun {
+	return &enemyGun{
+		rand:     r,
+		vmps:     getRandomValue(r, minVmps, maxVmps),
+		spread:   enemySpread,
+		nextShot: getRandomValue(r, enemyReloadSeconds/2.0, enemyReloadSeconds),
+	}
+}
+
+// fire shoots at the tank from distance at the simulated time now, unless the tank is out of range.
+func (gun *enemyGun) fire(now, distance float64) (flightTime float64, fired bool) {
+	gun.nextShot = now + enemyReloadSeconds
+	angle := xAngle(distance, gun.vmps)
+	if math.IsNaN(angle) {
+		return 0.0, false
+	}
+	_, flightTime = xRange(angle, gun.vmps)
+	gun.shells = append(gun.shells, enemyShell{landsAt: now + flightTime, miss: gun.rand.NormFloat64() * gun.spread * distance})
+	gun.spread = math.Max(gun.spread*enemySpreadFactor, minEnemySpread)
+	return flightTime, true
+}
+
+// land removes the shells that landed by the simulated time now and returns them.
+func (gun *enemyGun) land(now float64) (landed []enemyShell) {
+	shells := gun.shells[:0]
+	for _, shell := range gun.shells {
+		if shell.landsAt <= now {
+			landed = append(landed, shell)
+		} else {
+			shells = append(shells, shell)
+		}
+	}
+	gun.shells = shells
+	return landed
+}
+
+// enemyLanding is an enemyShell that landed, with the target that fired it.
+type enemyLanding struct {
+	enemyShell
+	target int
+}
+
+// enemyFire lets target i fire back at the simulated time now.
+func (g *Game) enemyFire(i int, now float64) {
+	t := &g.targets[i]
+	if flightTime, fired := t.gun.fire(now, t.distance); fired {
+		g.emit("enemy_fired", &enemyFiredEvent{Target: i + 1, FiredAt: now, Range: t.distance, FlightTime: flightTime})
+	}
+}
+
+// landShells reports the enemy shells that landed by the simulated time now, in the order that they
+// landed, and whether they destroyed the tank.
+func (g *Game) landShells(now float64) bool {
+	var landings []enemyLanding
+	for i, t := range g.targets {
+		if t.gun == nil {
+			continue
+		}
+		// A shell that is in the air still lands after its target is destroyed.
+		for _, shell := range t.gun.land(now) {
+			landings = append(landings, enemyLanding{shell, i})
+		}
+	}
+	sort.SliceStable(landings, func(a, b int) bool {
+		return landings[a].landsAt < landings[b].landsAt
+	})
+	for _, l := range landings {
+		if g.landShell(l) {
+			return true
+		}
+	}
+	return false
+}
+
+// landShell reports an enemy shell that landed, and whether it destroyed the tank.
+func (g *Game) landShell(l enemyLanding) bool {
+	name := g.getTargetName(l.target)
+	if len(g.targets) == 1 {
+		name = "the target"
+	}
+	shortOrLong := "short"
+	if l.miss < 0.0 {
+		shortOrLong = "long"
+	}
+	fmt.Fprintf(g.out, "Incoming shell from %s landed %s %s of you.\n", name, g.getDisplayText(math.Abs(l.miss)), shortOrLong)
+	g.incoming = append(g.incoming, l)
+	hit := math.Abs(l.miss) <= g.config.DeathRadius
+	if hit {
+		g.hitPoints--
+		fmt.Fprintf(g.out, "You were hit! %d of %d hit points left.\n", g.hitPoints, g.config.HitPoints)
+	}
+	g.emit("enemy_impact", &enemyImpactEvent{Target: l.target + 1, LandedAt: l.landsAt, Miss: l.miss, Hit: hit, HitPoints: g.hitPoints})
+	if g.hitPoints <= 0 {
+		fmt.Fprintln(g.out, "")
+		fmt.Fprintln(g.out, gameOverDestroyed)
+		fmt.Fprintln(g.out, "")
+		g.outcome = outcomeDestroyed
+		g.emit("destroyed", &destroyedEvent{Target: l.target + 1})
+		return true
+	}
+	return false
+}
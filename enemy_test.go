@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func Test_enemyGun_fire(t *testing.T) {
+	gun := &enemyGun{rand: rand.New(rand.NewSource(1)), vmps: 300.0, spread: enemySpread}
+	if _, fired := gun.fire(10.0, 20000.0); fired {
+		t.Errorf("fire() beyond the max range fired")
+	}
+	if gun.nextShot != 10.0+enemyReloadSeconds {
+		t.Errorf("fire() nextShot = %v, want %v", gun.nextShot, 10.0+enemyReloadSeconds)
+	}
+	flightTime, fired := gun.fire(30.0, 5000.0)
+	if _, wantTime := xRange(xAngle(5000.0, 300.0), 300.0); !fired || flightTime != wantTime {
+		t.Errorf("fire() = %v, %v, want %v, true", flightTime, fired, wantTime)
+	}
+	if gun.spread >= enemySpread {
+		t.Errorf("fire() spread = %v, want less than %v", gun.spread, enemySpread)
+	}
+	if landed := gun.land(30.0 + flightTime - 1.0); len(landed) != 0 {
+		t.Errorf("land() before the flight time = %v, want none", landed)
+	}
+	if landed := gun.land(30.0 + flightTime); len(landed) != 1 || len(gun.shells) != 0 {
+		t.Errorf("land() after the flight time = %v, want the shell", landed)
+	}
+}
+
+func TestGame_landShells(t *testing.T) {
+	tests := []struct {
+		name          string
+		misses        []float64
+		hitPoints     int
+		want          bool
+		wantHitPoints int
+		wantOutcome   outcome
+	}{
+		{
+			name:          "Misses",
+			misses:        []float64{100.0, -50.0},
+			hitPoints:     2,
+			want:          false,
+			wantHitPoints: 2,
+		},
+		{
+			name:          "Hit",
+			misses:        []float64{100.0, -10.0},
+			hitPoints:     2,
+			want:          false,
+			wantHitPoints: 1,
+		},
+		{
+			name:          "Destroyed",
+			misses:        []float64{5.0, -10.0},
+			hitPoints:     2,
+			want:          true,
+			wantHitPoints: 0,
+			wantOutcome:   outcomeDestroyed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gun := &enemyGun{}
+			for i, miss := range tt.misses {
+				gun.shells = append(gun.shells, enemyShell{landsAt: float64(i), miss: miss})
+			}
+			g := &Game{config: Config{DeathRadius: impactRadius, HitPoints: tt.hitPoints}, out: io.Discard, targets: []target{{gun: gun}}, hitPoints: tt.hitPoints}
+			if got := g.landShells(float64(len(tt.misses))); got != tt.want {
+				t.Errorf("landShells() = %v, want %v", got, tt.want)
+			}
+			if g.hitPoints != tt.wantHitPoints || g.outcome != tt.wantOutcome || len(g.incoming) != len(tt.misses) {
+				t.Errorf("landShells() hitPoints = %d, outcome = %q, incoming = %d, want %d, %q, %d", g.hitPoints, g.outcome, len(g.incoming), tt.wantHitPoints, tt.wantOutcome, len(tt.misses))
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// eventsVersion changes whenever an event or a field is changed or removed. New events and new
+// fields may be added without changing it.
+const eventsVersion = 1
+
+// eventHeader starts every event in the stream.
+type eventHeader struct {
+	Type string  `json:"type"`
+	Time float64 `json:"time"` // simulated seconds since the start of the game
+}
+
+func (h *eventHeader) header() *eventHeader {
+	return h
+}
+
+type event interface {
+	header() *eventHeader
+}
+
+type gameStartEvent struct {
+	eventHeader
+	Version        int           `json:"version"`
+	Config         Config        `json:"config"` // with the Seed that was used
+	ProjectileVmps float64       `json:"projectile_vmps"`
+	MaxRange       float64       `json:"max_range"`
+	TargetVmps     float64       `json:"target_vmps"`
+	TargetRange    float64       `json:"target_range"` // of the first target
+	Targets        []targetState `json:"targets"`
+	Wind           float64       `json:"wind_vmps"`
+}
+
+type awaitingShotEvent struct {
+	eventHeader
+	Shot int     `json:"shot"`
+	Wind float64 `json:"wind_vmps"`
+}
+
+type shotFiredEvent struct {
+	eventHeader
+	Shot       int     `json:"shot"`
+	Angle      float64 `json:"angle"`
+	Azimuth    float64 `json:"azimuth"`
+	Range      float64 `json:"range"`
+	FlightTime float64 `json:"flight_time"`
+	Wind       float64 `json:"wind_vmps"`
+}
+
+type shotImpactEvent struct {
+	eventHeader
+	Shot        int     `json:"shot"`
+	Angle       float64 `json:"angle"`
+	Azimuth     float64 `json:"azimuth"`
+	Range       float64 `json:"range"`
+	FlightTime  float64 `json:"flight_time"`
+	Delta       float64 `json:"delta"`   // + = undershot, - = overshot
+	Lateral     float64 `json:"lateral"` // + = the target is right of the line of fire, - = left
+	TargetRange float64 `json:"target_range"`
+	Target      int     `json:"target"` // the target nearest to the impact
+}
+
+type targetMovedEvent struct {
+	eventHeader
+	TargetRange float64 `json:"target_range"`
+	Target      int     `json:"target"`
+}
+
+type hitEvent struct {
+	eventHeader
+	Shot   int     `json:"shot"`
+	Delta  float64 `json:"delta"`
+	Target int     `json:"target"`
+	Left   int     `json:"left"` // targets left, the game is won at 0
+}
+
+type crushedEvent struct {
+	eventHeader
+	TargetRange float64 `json:"target_range"`
+}
+
+type enemyFiredEvent struct {
+	eventHeader
+	Target     int     `json:"target"`
+	FiredAt    float64 `json:"fired_at"`
+	Range      float64 `json:"range"` // to the tank
+	FlightTime float64 `json:"flight_time"`
+}
+
+type enemyImpactEvent struct {
+	eventHeader
+	Target    int     `json:"target"`
+	LandedAt  float64 `json:"landed_at"`
+	Miss      float64 `json:"miss"` // + = short of the tank, - = long
+	Hit       bool    `json:"hit"`
+	HitPoints int     `json:"hit_points"` // left
+}
+
+type destroyedEvent struct {
+	eventHeader
+	Target int `json:"target"`
+}
+
+type quitEvent struct {
+	eventHeader
+	Shots int `json:"shots"`
+}
+
+type outOfAmmoEvent struct {
+	eventHeader
+	Shots int `json:"shots"`
+}
+
+// streamEvents writes every game event to w as a line of JSON.
+func (g *Game) streamEvents(w io.Writer) {
+	g.events = json.NewEncoder(w)
+}
+
+// emit stamps e with its type and the simulation time and writes it to the event stream, if any.
+func (g *Game) emit(eventType string, e event) {
+	if g.events == nil {
+		return
+	}
+	h := e.header()
+	h.Type = eventType
+	h.Time = g.clock.Now().Seconds()
+	g.eventsMu.Lock()
+	defer g.eventsMu.Unlock()
+	g.events.Encode(e)
+}
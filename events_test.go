@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestGame_streamEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    Config
+		input     string
+		wantFirst string
+		wantLast  string
+	}{
+		{
+			name:      "Auto Shot - Hit",
+			config:    withDefaults(Config{ShootModeAuto: true, DeathRadius: impactRadius, Speed: "instant", Seed: 3}),
+			wantFirst: "game_start",
+			wantLast:  "hit",
+		},
+		{
+			name:      "Manual Shot - Quit",
+			config:    withDefaults(Config{DeathRadius: impactRadius, Seed: 3}),
+			input:     "10\n0\n",
+			wantFirst: "game_start",
+			wantLast:  "quit",
+		},
+		{
+			name:      "Auto Shot - Out of Ammunition",
+			config:    withDefaults(Config{ShootModeAuto: true, DeathRadius: impactRadius, Speed: "instant", Seed: 3, MaxShots: 1, Strategy: "bisect"}),
+			wantFirst: "game_start",
+			wantLast:  "out_of_ammo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGame(tt.config, strings.NewReader(tt.input), io.Discard)
+			if err != nil {
+				t.Fatalf("NewGame() error = %v", err)
+			}
+			var events bytes.Buffer
+			g.streamEvents(&events)
+			g.Run(context.Background())
+
+			var types []string
+			counts := map[string]int{}
+			lastTime := 0.0
+			scanner := bufio.NewScanner(&events)
+			for scanner.Scan() {
+				var h eventHeader
+				if err := json.Unmarshal(scanner.Bytes(), &h); err != nil {
+					t.Fatalf("event %q is not JSON: %v", scanner.Text(), err)
+				}
+				if h.Time < lastTime {
+					t.Errorf("event %q goes back in time from %v", scanner.Text(), lastTime)
+				}
+				lastTime = h.Time
+				types = append(types, h.Type)
+				counts[h.Type]++
+			}
+			if len(types) == 0 || types[0] != tt.wantFirst || types[len(types)-1] != tt.wantLast {
+				t.Fatalf("events = %v, want %s first and %s last", types, tt.wantFirst, tt.wantLast)
+			}
+			if counts["shot_fired"] != len(g.shots) || counts["shot_impact"] != len(g.shots) {
+				t.Errorf("events = %v, want a shot_fired and a shot_impact for each of %d shots", counts, len(g.shots))
+			}
+		})
+	}
+}
+
+func TestGame_emit_gameStart(t *testing.T) {
+	g, err := NewGame(withDefaults(Config{DeathRadius: impactRadius, Seed: 5}), strings.NewReader(""), io.Discard)
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	var events bytes.Buffer
+	g.streamEvents(&events)
+	g.clock = newManualClock()
+	g.printConfiguration()
+
+	var e gameStartEvent
+	if err := json.Unmarshal(events.Bytes(), &e); err != nil {
+		t.Fatalf("game_start %q is not JSON: %v", events.String(), err)
+	}
+	if e.Type != "game_start" || e.Version != eventsVersion || e.Config != g.config || e.TargetRange != g.targets[0].distance || len(e.Targets) != 1 {
+		t.Errorf("game_start = %+v, want the configuration and the starting values of %+v", e, g.config)
+	}
+}
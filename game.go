@@ -0,0 +1,983 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config holds everything needed to set up a Game.
+type Config struct {
+	ShootModeAuto     bool    `json:"shoot_mode_auto"`     // true = Auto Shoot Mode, false = Manual Shot
+	TargetModeAuto    bool    `json:"target_mode_auto"`    // true = target moves when deciding shot, false = target pauses when deciding shot
+	EnglishUnits      bool    `json:"english_units"`       // true = english units, false = metric units
+	PrintShotProfile  bool    `json:"print_shot_profile"`  // true = print the shot profile on startup, false = don't
+	HighAngle         bool    `json:"high_angle"`          // true = allow high-angle fire and shoot the high arc in Auto Shot Mode
+	Strategy          string  `json:"strategy"`            // how Auto Shot Mode chooses the next shot angle, "" = heuristic
+	DeathRadius       float64 `json:"death_radius"`        // meters
+	Speed             string  `json:"speed"`               // times faster than real-time, or "instant" (default depends on the shot mode)
+	Seed              int64   `json:"seed"`                // seed for the random starting values, any value including 0
+	Drag              bool    `json:"drag"`                // true = air resistance slows the projectile, false = vacuum
+	DragCoefficient   float64 `json:"drag_coefficient"`    // used when Drag is set
+	ProjectileMass    float64 `json:"projectile_mass"`     // kilograms, used when Drag is set
+	Caliber           float64 `json:"caliber"`             // millimeters, used when Drag is set
+	Wind              float64 `json:"wind"`                // kilometers/hour, + = tail wind, - = head wind
+	WindGust          float64 `json:"wind_gust"`           // kilometers/hour that the wind can drift away from Wind, changing every shot
+	Elevation         float64 `json:"elevation"`           // meters of the target area above (+) or below (-) the tank
+	Terrain           float64 `json:"terrain"`             // meters of rolling hills above and below Elevation, 0 = flat
+	MaxShots          int     `json:"max_shots"`           // the battle manager runs out of ammunition after this many shots, 0 = never
+	Difficulty        string  `json:"difficulty"`          // the preset that the other options started from
+	MinProjectileVmps float64 `json:"min_projectile_vmps"` // the projectile velocity is random in this range
+	MaxProjectileVmps float64 `json:"max_projectile_vmps"`
+	MinTargetVkph     float64 `json:"min_target_vkph"` // the target velocity is random in this range
+	MaxTargetVkph     float64 `json:"max_target_vkph"`
+	MinStartRange     float64 `json:"min_start_range"` // the starting target range is random in this range, as fractions of the max range
+	MaxStartRange     float64 `json:"max_start_range"`
+	MinStartMeters    float64 `json:"min_start_meters"` // replaces MinStartRange when set, 0 = not set
+	MaxStartMeters    float64 `json:"max_start_meters"` // replaces MaxStartRange when set, 0 = not set
+	Targets           int     `json:"targets"`          // number of targets closing in at the same time, 0 = 1
+	TargetBehavior    string  `json:"target_behavior"`  // how the targets close in, "" = constant
+	ReturnFire        bool    `json:"return_fire"`      // true = the targets fire back at the tank
+	HitPoints         int     `json:"hit_points"`       // hits that the tank takes before it is destroyed, with ReturnFire
+	TwoD              bool    `json:"two_d"`            // true = the targets close in from a bearing on a plane, aimed at with an azimuth
+	BotTimeout        float64 `json:"bot_timeout"`      // seconds that a bot strategy has to reply, 0 = 5
+}
+
+// outcome is how a game ended.
+type outcome string
+
+const (
+	outcomeNone       outcome = ""            // still playing, or cancelled
+	outcomeHit        outcome = "hit"         // every target was destroyed
+	outcomeCrushed    outcome = "crushed"     // a target reached the tank
+	outcomeDestroyed  outcome = "destroyed"   // the targets shot the tank to pieces
+	outcomeQuit       outcome = "quit"        // the player quit
+	outcomeOutOfShots outcome = "out_of_ammo" // MaxShots were taken without a hit
+)
+
+// gameResult summarizes a game once it is over.
+type gameResult struct {
+	Outcome     outcome
+	Shots       int
+	Time        float64 // simulated seconds since the start of the game
+	TargetRange float64 // the closest a target came to the tank
+}
+
+// target is one of the enemy tanks closing in on the tank.
+type target struct {
+	vkph      float64
+	vmps      float64
+	distance  float64 // meters from the tank
+	destroyed bool
+	behavior  behavior  // nil = constant
+	gun       *enemyGun // nil = the target doesn't fire back
+	bearing   float64   // degrees clockwise from north, 0 unless TwoD
+}
+
+// targetState is what the player can see of a target.
+type targetState struct {
+	Target    int     `json:"target"` // numbered from 1
+	Vmps      float64 `json:"vmps"`   // the velocity that it closes in at right now
+	Range     float64 `json:"range"`
+	Bearing   float64 `json:"bearing"`
+	Destroyed bool    `json:"destroyed"`
+}
+
+// Game owns the configuration and the live state of a single battle.
+type Game struct {
+	config                Config
+	input                 io.Reader
+	out                   io.Writer
+	seed                  int64
+	rand                  *rand.Rand
+	projectileVmps        float64
+	ballistics            ballistics
+	windKph               float64 // wind for the next shot, + = tail wind, - = head wind
+	maxRange              float64
+	targets               []target
+	targetModeAuto        bool    // true = target moves when deciding shot, false = target pauses when deciding shot
+	targetSpeedMultiplier float64 // times faster than real-time, +Inf = instant
+	width                 int     // characters of the timeline
+	flightPath            string
+	impactPath            string
+	rulerText             string
+	lines                 <-chan string
+	clock                 Clock // created by Run unless one is injected first
+	strategy              strategy
+	shots                 []shotResult
+	recordedShots         []recordedShot
+	replay                *recording // the shots come from the recording instead of the player
+	replayControls        *replayControls
+	events                *json.Encoder // nil = no event stream
+	eventsMu              sync.Mutex
+	live                  bool  // a live display shows the header and the timeline, instead of printing them for every shot
+	color                 bool  // ANSI colors in the timeline and the results of a shot
+	duel                  *duel // the hot-seat duel that the game is the board of, the target is the other tank
+
+	mu        sync.Mutex // guards targets, hitPoints, incoming, gameOver, outcome and the wind while the targets move in real-time or a live display draws them
+	hitPoints int
+	incoming  []enemyLanding // the enemy shells that landed since the last timeline
+	gameOver  bool
+	outcome   outcome
+}
+
+// NewGame creates a Game from config, drawing the random starting values.
+func NewGame(config Config, input io.Reader, out io.Writer) (*Game, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	g := &Game{
+		config: config,
+		input:  input,
+		out:    out,
+	}
+	g.targetModeAuto, g.targetSpeedMultiplier = getTargetMode(config.ShootModeAuto, config.TargetModeAuto)
+	var err error
+	if config.Speed != "" {
+		if g.targetSpeedMultiplier, err = parseSpeedMultiplier(config.Speed); err != nil {
+			return nil, err
+		}
+	}
+	if g.config.Strategy == "" {
+		g.config.Strategy = defaultStrategy
+	}
+	if g.strategy, err = newStrategy(g.config.Strategy); err != nil {
+		return nil, err
+	}
+	if b, ok := g.strategy.(*botStrategy); ok {
+		b.log = out
+		if config.BotTimeout > 0.0 {
+			b.timeout = time.Duration(config.BotTimeout * float64(time.Second))
+		}
+	}
+	if config.Drag {
+		if g.ballistics.drag, err = newDragModel(config.DragCoefficient, config.ProjectileMass, config.Caliber); err != nil {
+			return nil, err
+		}
+	}
+
+	// Initialize random values. The same seed always produces the same scenario.
+	g.seed = config.Seed
+	g.rand = rand.New(rand.NewSource(g.seed))
+	g.projectileVmps = getRandomValue(g.rand, config.MinProjectileVmps, config.MaxProjectileVmps)
+	g.targets = make([]target, 1, getTargetCount(config.Targets))
+	g.targets[0].vkph = getRandomValue(g.rand, config.MinTargetVkph, config.MaxTargetVkph)
+	// The battlefield is sized for still air on level ground, the wind and the terrain only move the
+	// shots around on it.
+	g.maxRange = g.ballistics.maxRange(g.projectileVmps)
+	// A start range in meters has to be in range of the slowest projectile. A min without a max has to
+	// be below the random max start range of the slowest projectile too, and a max without a min above
+	// the random min start range of the fastest projectile, so that no seed fails.
+	if start := math.Max(config.MinStartMeters, config.MaxStartMeters); start > 0.0 {
+		slowestRange := g.ballistics.maxRange(config.MinProjectileVmps)
+		if start > slowestRange {
+			return nil, fmt.Errorf("invalid start range %g meters: beyond the max range of %.1f meters at %g meters/sec", start, slowestRange, config.MinProjectileVmps)
+		}
+		if slowestMax := slowestRange * config.MaxStartRange; config.MaxStartMeters == 0.0 && start > slowestMax {
+			return nil, fmt.Errorf("invalid min start range %g meters: beyond the max start range of %.1f meters at %g meters/sec", start, slowestMax, config.MinProjectileVmps)
+		}
+		if fastestMin := g.ballistics.maxRange(config.MaxProjectileVmps) * config.MinStartRange; config.MinStartMeters == 0.0 && start < fastestMin {
+			return nil, fmt.Errorf("invalid max start range %g meters: below the min start range of %.1f meters at %g meters/sec", start, fastestMin, config.MaxProjectileVmps)
+		}
+	}
+	minStart, maxStart := g.getStartRange()
+	if maxStart < minStart {
+		return nil, fmt.Errorf("invalid start range from %.1f to %.1f meters: want min <= max", minStart, maxStart)
+	}
+	g.setWind(config.Wind)
+	g.targets[0].distance = getRandomValue(g.rand, minStart, maxStart)
+	g.ballistics.terrain = newTerrain(g.rand, config.Elevation, config.Terrain, g.maxRange)
+	// The other targets are drawn last, so that the first one is the same as in a single target game.
+	for len(g.targets) < cap(g.targets) {
+		vkph := getRandomValue(g.rand, config.MinTargetVkph, config.MaxTargetVkph)
+		g.targets = append(g.targets, target{vkph: vkph, distance: getRandomValue(g.rand, minStart, maxStart)})
+	}
+	if g.config.TargetBehavior == "" {
+		g.config.TargetBehavior = defaultBehavior
+	}
+	for i := range g.targets {
+		g.targets[i].vmps = g.targets[i].vkph * (metersPerKilometer / secondsPerHour)
+		// Only the unpredictable behaviors draw random values, so a constant target leaves the seeded
+		// scenario as it was.
+		var r *rand.Rand
+		if g.config.TargetBehavior != defaultBehavior {
+			r = rand.New(rand.NewSource(g.rand.Int63()))
+		}
+		if g.targets[i].behavior, err = newBehavior(g.config.TargetBehavior, r); err != nil {
+			return nil, err
+		}
+	}
+	if config.ReturnFire {
+		g.hitPoints = config.HitPoints
+		for i := range g.targets {
+			g.targets[i].gun = newEnemyGun(rand.New(rand.NewSource(g.rand.Int63())), config.MinProjectileVmps, config.MaxProjectileVmps)
+		}
+	}
+	if config.TwoD {
+		for i := range g.targets {
+			g.targets[i].bearing = getRandomValue(g.rand, 0.0, 360.0)
+		}
+	}
+
+	g.setTimelineWidth(defaultTimelineWidth)
+	return g, nil
+}
+
+// Run prints the startup configuration and plays the game until it is over or ctx is cancelled.
+// Whichever of the battle manager and the target movement finishes first stops the other.
+func (g *Game) Run(ctx context.Context) {
+	if g.clock == nil {
+		g.clock = g.newClock()
+	}
+
+	g.printConfiguration()
+	if g.config.PrintShotProfile {
+		g.displayShotProfile()
+	}
+	if !g.config.ShootModeAuto && g.replay == nil && g.lines == nil {
+		g.lines = readLines(g.input)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if c, ok := g.clock.(*pacedClock); ok {
+		go c.run(ctx)
+	}
+
+	var wg sync.WaitGroup
+	if g.targetModeAuto {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cancel()
+			g.targetMovement(ctx)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+		g.battleManager(ctx)
+	}()
+	wg.Wait()
+	// A bot strategy is hung up on once the game is over.
+	if c, ok := g.strategy.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// result returns how the game ended, once Run has returned.
+func (g *Game) result() gameResult {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	closest := math.Inf(1)
+	for _, t := range g.targets {
+		closest = math.Min(closest, t.distance)
+	}
+	return gameResult{
+		Outcome:     g.outcome,
+		Shots:       len(g.shots),
+		Time:        g.clock.Now().Seconds(),
+		TargetRange: closest,
+	}
+}
+
+// getTargetCount returns the number of targets, with 0 meaning a single target.
+func getTargetCount(targets int) int {
+	if targets < 1 {
+		return 1
+	}
+	return targets
+}
+
+// closestTarget returns the index of the target that is left closest to the tank, or -1 when every
+// target is destroyed.
+func (g *Game) closestTarget() int {
+	closest := -1
+	for i, t := range g.targets {
+		if !t.destroyed && (closest < 0 || t.distance < g.targets[closest].distance) {
+			closest = i
+		}
+	}
+	return closest
+}
+
+// nearestTarget returns the index of the target that is left closest to where a shot landed, or -1
+// when every target is destroyed.
+func (g *Game) nearestTarget(shotRange, shotAzimuth float64) int {
+	nearest, nearestMiss := -1, 0.0
+	for i, t := range g.targets {
+		if t.destroyed {
+			continue
+		}
+		if miss := math.Hypot(g.getMiss(i, shotRange, shotAzimuth)); nearest < 0 || miss < nearestMiss {
+			nearest, nearestMiss = i, miss
+		}
+	}
+	return nearest
+}
+
+// getMiss returns how far target i is beyond (+) or short (-) of a shot along the line of fire, and
+// how far it is to the right (+) or left (-) of the line of fire.
+func (g *Game) getMiss(i int, shotRange, shotAzimuth float64) (delta, lateral float64) {
+	t := g.targets[i]
+	radians := (t.bearing - shotAzimuth) * math.Pi / 180.0
+	return t.distance*math.Cos(radians) - shotRange, t.distance * math.Sin(radians)
+}
+
+// targetsLeft returns the number of targets that aren't destroyed yet.
+func (g *Game) targetsLeft() int {
+	left := 0
+	for _, t := range g.targets {
+		if !t.destroyed {
+			left++
+		}
+	}
+	return left
+}
+
+// targetStates returns what can be seen of every target.
+func (g *Game) targetStates() []targetState {
+	now := g.clock.Now().Seconds()
+	states := make([]targetState, len(g.targets))
+	for i, t := range g.targets {
+		states[i] = targetState{Target: i + 1, Vmps: t.vmps * t.factorAt(now), Range: t.distance, Bearing: t.bearing, Destroyed: t.destroyed}
+	}
+	return states
+}
+
+// getTargetName names a target in the messages, the targets are only numbered when there are several.
+func (g *Game) getTargetName(i int) string {
+	if g.duel != nil {
+		return g.duel.getOpponentName()
+	}
+	if len(g.targets) == 1 {
+		return "target"
+	}
+	return fmt.Sprintf("target #%d", i+1)
+}
+
+// getTargetMarker returns the mark of a target on the impact timeline.
+func (g *Game) getTargetMarker(i int) string {
+	if len(g.targets) == 1 {
+		return "T"
+	}
+	return strconv.Itoa(i + 1)
+}
+
+func (g *Game) printConfiguration() {
+	if g.config.ShootModeAuto {
+		fmt.Fprintln(g.out, "Shot Mode: Auto")
+		fmt.Fprintf(g.out, "Strategy: %s\n", g.config.Strategy)
+	} else {
+		fmt.Fprintln(g.out, "Shot Mode: Manual")
+		if g.targetModeAuto {
+			fmt.Fprintln(g.out, "Target Mode: Realtime Target Movement")
+		} else {
+			fmt.Fprintln(g.out, "Target Mode: Pause Target During Shot Decision")
+		}
+	}
+	if g.targetModeAuto {
+		fmt.Fprintf(g.out, "Game Speed: %s\n", getSpeedText(g.targetSpeedMultiplier))
+	}
+	if g.config.Difficulty != "" && g.config.Difficulty != defaultDifficulty {
+		fmt.Fprintf(g.out, "Difficulty: %s\n", g.config.Difficulty)
+	}
+	fmt.Fprintf(g.out, "Seed: %d\n", g.seed)
+	fmt.Fprintf(g.out, "Units: %s\n", englishOrMetric[g.config.EnglishUnits])
+	fmt.Fprintf(g.out, "Detonation Radius = %s\n", g.getDisplayText(g.config.DeathRadius))
+	fmt.Fprintf(g.out, "Projectile Velocity: %s\n", getRandomRangeText(g.config.MinProjectileVmps, g.config.MaxProjectileVmps, func(v float64) string {
+		return g.getDisplayText(v) + "/sec"
+	}))
+	if len(g.targets) > 1 {
+		fmt.Fprintf(g.out, "Targets: %d\n", len(g.targets))
+	}
+	fmt.Fprintf(g.out, "Target Velocity: %s\n", getRandomRangeText(g.config.MinTargetVkph, g.config.MaxTargetVkph, g.getVelocityText))
+	if g.config.TargetBehavior != defaultBehavior {
+		fmt.Fprintf(g.out, "Target Behavior: %s\n", g.config.TargetBehavior)
+	}
+	if g.config.ReturnFire {
+		fmt.Fprintf(g.out, "Return Fire: the targets fire back, %d hit points\n", g.config.HitPoints)
+	}
+	if g.config.TwoD {
+		fmt.Fprintln(g.out, "Battlefield: 2D, aim with an angle and an azimuth (degrees clockwise from north)")
+	}
+	minStart, maxStart := g.getStartRange()
+	fmt.Fprintf(g.out, "Starting Target Range: %s\n", getRandomRangeText(minStart, maxStart, g.getDisplayText))
+	if g.config.HighAngle {
+		fmt.Fprintf(g.out, "High-Angle Fire: up to %3.1f degrees\n", maxHighShotAngle)
+	}
+	if g.ballistics.drag != nil {
+		fmt.Fprintf(g.out, "Air Drag: Coefficient = %.2f, Mass = %.1f kg, Caliber = %.0f mm\n", g.config.DragCoefficient, g.config.ProjectileMass, g.config.Caliber)
+	}
+	g.emitGameStart()
+}
+
+func (g *Game) emitGameStart() {
+	g.emit("game_start", &gameStartEvent{
+		Version:        eventsVersion,
+		Config:         g.config,
+		ProjectileVmps: g.projectileVmps,
+		MaxRange:       g.maxRange,
+		TargetVmps:     g.targets[0].vmps,
+		TargetRange:    g.targets[0].distance,
+		Targets:        g.targetStates(),
+		Wind:           g.ballistics.wind,
+	})
+}
+
+// displayShotProfile prints the range and flight time for every angle, landing at the current height
+// of the target.
+func (g *Game) displayShotProfile() {
+	b := g.ballistics
+	b.terrain = terrain{elevation: g.targetElevation(g.targets[g.closestTarget()].distance)}
+	fmt.Fprintln(g.out, "")
+	fmt.Fprintln(g.out, "Shot Profile:")
+	if g.hasElevation() {
+		fmt.Fprintf(g.out, "(landing at the Target Elevation of %s)\n", g.getDisplayText(b.terrain.elevation))
+	}
+	if g.config.HighAngle {
+		g.displayHighShotProfile(b)
+		return
+	}
+	fmt.Fprintf(g.out, "+-------+------------+-------+\n")
+	fmt.Fprintf(g.out, "| Angle | Shot Range | Time  |\n")
+	fmt.Fprintf(g.out, "| (deg) | %10s | (sec) |\n", "("+feetOrMeters[g.config.EnglishUnits]+")")
+	fmt.Fprintf(g.out, "+-------+------------+-------+\n")
+	for angle := minShotAngle; angle <= maxShotAngle; angle += 1.0 {
+		shotRange, shotTime := b.flight(angle, g.projectileVmps)
+		fmt.Fprintf(g.out, "| %5.1f | %10.1f | %5.1f |\n", angle, getFeetOrMeters(shotRange, g.config.EnglishUnits), shotTime)
+	}
+	fmt.Fprintf(g.out, "+-------+------------+-------+\n")
+	fmt.Fprintln(g.out, "")
+}
+
+// displayHighShotProfile prints the low and the high angle that reach the same range side by side.
+func (g *Game) displayHighShotProfile(b ballistics) {
+	fmt.Fprintf(g.out, "+-------+------------+-------+-------+-------+\n")
+	fmt.Fprintf(g.out, "|  Low  | Shot Range | Time  | High  | Time  |\n")
+	fmt.Fprintf(g.out, "| (deg) | %10s | (sec) | (deg) | (sec) |\n", "("+feetOrMeters[g.config.EnglishUnits]+")")
+	fmt.Fprintf(g.out, "+-------+------------+-------+-------+-------+\n")
+	for angle := minShotAngle; angle <= maxShotAngle; angle += 1.0 {
+		shotRange, shotTime := b.flight(angle, g.projectileVmps)
+		highAngle := b.highAngleFor(shotRange, g.projectileVmps)
+		if math.IsNaN(highAngle) || highAngle > maxHighShotAngle || highAngle < angle {
+			fmt.Fprintf(g.out, "| %5.1f | %10.1f | %5.1f |   --- |   --- |\n", angle, getFeetOrMeters(shotRange, g.config.EnglishUnits), shotTime)
+			continue
+		}
+		_, highTime := b.flight(highAngle, g.projectileVmps)
+		fmt.Fprintf(g.out, "| %5.1f | %10.1f | %5.1f | %5.1f | %5.1f |\n", angle, getFeetOrMeters(shotRange, g.config.EnglishUnits), shotTime, highAngle, highTime)
+	}
+	fmt.Fprintf(g.out, "+-------+------------+-------+-------+-------+\n")
+	fmt.Fprintln(g.out, "")
+}
+
+// getStartRange returns the range of the starting target range in meters.
+func (g *Game) getStartRange() (min, max float64) {
+	min, max = g.maxRange*g.config.MinStartRange, g.maxRange*g.config.MaxStartRange
+	if g.config.MinStartMeters > 0.0 {
+		min = g.config.MinStartMeters
+	}
+	if g.config.MaxStartMeters > 0.0 {
+		max = g.config.MaxStartMeters
+	}
+	return
+}
+
+// maxAngle returns the steepest shot angle that the player can take.
+func (g *Game) maxAngle() float64 {
+	if g.config.HighAngle {
+		return maxHighShotAngle
+	}
+	return maxShotAngle
+}
+
+func (g *Game) getDisplayText(value float64) string {
+	return fmt.Sprintf("%3.1f %s", getFeetOrMeters(value, g.config.EnglishUnits), feetOrMeters[g.config.EnglishUnits])
+}
+
+// getVelocityText converts kilometers/hour into the display units.
+func (g *Game) getVelocityText(vkph float64) string {
+	return fmt.Sprintf("%3.1f %s/hour", getMilesOrKilometers(vkph*metersPerKilometer, g.config.EnglishUnits), milesOrKilometers[g.config.EnglishUnits])
+}
+
+// getRandomRangeText tells whether a starting value is fixed or random, and from which range.
+func getRandomRangeText(min, max float64, text func(float64) string) string {
+	if min == max {
+		return "fixed at " + text(min)
+	}
+	return fmt.Sprintf("random from %s to %s", text(min), text(max))
+}
+
+// getFixedText marks the values that the options fixed in the header.
+func getFixedText(min, max float64) string {
+	if min == max {
+		return " (fixed)"
+	}
+	return ""
+}
+
+func (g *Game) printHeader() {
+	if g.live {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.writeHeader(g.out)
+}
+
+// writeHeader writes the header to w, the caller holds g.mu.
+func (g *Game) writeHeader(w io.Writer) {
+	englishUnits := g.config.EnglishUnits
+	fmt.Fprintln(w, "==================================")
+	fmt.Fprintf(w, "Projectile Velocity  = %s/sec%s\n", g.getDisplayText(g.projectileVmps), getFixedText(g.config.MinProjectileVmps, g.config.MaxProjectileVmps))
+	fmt.Fprintf(w, "Max Projectile Range = %s\n", g.getDisplayText(g.maxRange))
+	if g.config.ReturnFire {
+		fmt.Fprintf(w, "Hit Points           = %d of %d\n", g.hitPoints, g.config.HitPoints)
+	}
+	if g.config.Wind != 0.0 || g.config.WindGust > 0.0 {
+		fmt.Fprintf(w, "Wind Velocity        = %3.1f %s/hour (%s)\n", getMilesOrKilometers(math.Abs(g.windKph)*metersPerKilometer, englishUnits), milesOrKilometers[englishUnits], getWindText(g.windKph))
+	}
+	if len(g.targets) > 1 {
+		g.printTargets(w)
+		fmt.Fprintln(w, "----------------------------------")
+		return
+	}
+	t := g.targets[0]
+	factor := t.factorAt(g.clock.Now().Seconds())
+	fmt.Fprintf(w, "Target Velocity      = %s%s\n", g.getVelocityText(t.vkph*factor), getFixedText(g.config.MinTargetVkph, g.config.MaxTargetVkph))
+	fmt.Fprintf(w, "Target Velocity      = %s/sec\n", g.getDisplayText(t.vmps*factor))
+	fmt.Fprintf(w, "Current Target Range = %3.1f %s\n", getMilesOrKilometers(t.distance, englishUnits), milesOrKilometers[englishUnits])
+	fmt.Fprintf(w, "Current Target Range = %s\n", g.getDisplayText(t.distance))
+	if g.config.TwoD {
+		fmt.Fprintf(w, "Target Bearing       = %3.1f degrees\n", t.bearing)
+	}
+	if g.hasElevation() {
+		fmt.Fprintf(w, "Target Elevation     = %s\n", g.getDisplayText(g.targetElevation(t.distance)))
+	}
+	fmt.Fprintln(w, "----------------------------------")
+}
+
+// printTargets prints a line for each of several targets in the header.
+func (g *Game) printTargets(w io.Writer) {
+	now := g.clock.Now().Seconds()
+	for i, t := range g.targets {
+		name := fmt.Sprintf("Target #%d", i+1)
+		if t.destroyed {
+			fmt.Fprintf(w, "%-21s= destroyed\n", name)
+			continue
+		}
+		bearing := ""
+		if g.config.TwoD {
+			bearing = fmt.Sprintf(", bearing %3.1f degrees", t.bearing)
+		}
+		fmt.Fprintf(w, "%-21s= %s at %s%s\n", name, g.getDisplayText(t.distance), g.getVelocityText(t.vkph*t.factorAt(now)), bearing)
+		if g.hasElevation() {
+			fmt.Fprintf(w, "%-21s= %s\n", name+" Elevation", g.getDisplayText(g.targetElevation(t.distance)))
+		}
+	}
+}
+
+// printImpactTimeline draws the shot and the target that it was measured against, and every target
+// that is left. The 2D battlefield is drawn as a map instead.
+func (g *Game) printImpactTimeline(shot shotResult, hit bool) {
+	// A live display draws the timeline and the enemy shells itself.
+	if g.live {
+		return
+	}
+	if g.duel != nil {
+		g.duel.printTimeline(shot.Range, hit)
+		return
+	}
+	if g.config.TwoD {
+		g.printMap(shot, hit)
+		return
+	}
+	// The wind can carry a shot past the end of the timeline.
+	shotDistance := math.Min(shot.Range, g.maxRange)
+
+	target := shot.Target - 1
+	targetDistance := g.targets[target].distance
+	shotIndex, targetIndex := getImpactTimelineIndices(shotDistance, targetDistance, g.maxRange, g.width)
+	curFlightPath := g.flightPath
+	curImpactPath := g.impactPath
+	// The enemy shells and the other targets go first, so that the shot and its target are drawn on top
+	// of them. An enemy shell is marked as far from the tank as it landed.
+	for _, l := range g.incoming {
+		index := getTimelineIndex(math.Abs(l.miss), g.maxRange, g.width)
+		curImpactPath = curImpactPath[:index-1] + "x" + curImpactPath[index:]
+	}
+	g.incoming = nil
+	for i, t := range g.targets {
+		if i != target && !t.destroyed {
+			index := getTimelineIndex(t.distance, g.maxRange, g.width)
+			curImpactPath = curImpactPath[:index-1] + g.getTargetMarker(i) + curImpactPath[index:]
+		}
+	}
+	if hit {
+		curFlightPath = curFlightPath[:targetIndex-2] + "\\"
+		curImpactPath = curImpactPath[:targetIndex-1] + "*" + curImpactPath[targetIndex:]
+	} else {
+		curFlightPath = curFlightPath[:shotIndex-2] + "\\"
+		curImpactPath = curImpactPath[:shotIndex-1] + "\\" + curImpactPath[shotIndex:]
+		curImpactPath = curImpactPath[:targetIndex-1] + g.getTargetMarker(target) + curImpactPath[targetIndex:]
+	}
+	fmt.Fprintln(g.out, "")
+	fmt.Fprintln(g.out, curFlightPath)
+	fmt.Fprintln(g.out, g.paintTimeline(curImpactPath))
+	if !g.ballistics.terrain.level() {
+		fmt.Fprintln(g.out, g.getTerrainText())
+	}
+	fmt.Fprintln(g.out, g.rulerText)
+	if g.hasElevation() {
+		fmt.Fprintf(g.out, "Target Elevation = %s\n", g.getDisplayText(g.targetElevation(targetDistance)))
+	}
+	fmt.Fprintln(g.out, "")
+}
+
+// getTimelineIndex returns where distance is on an impact path width characters wide, leaving the tank
+// at the start.
+func getTimelineIndex(distance, maxDistance float64, width int) int {
+	index := int(distance/maxDistance*float64(width-1)) + 1
+	return int(math.Max(2, math.Min(float64(width), float64(index))))
+}
+
+// hasElevation reports whether the target is ever above or below the tank.
+func (g *Game) hasElevation() bool {
+	return !g.ballistics.terrain.level() || g.ballistics.terrain.elevation != 0.0
+}
+
+func (g *Game) targetElevation(targetRange float64) float64 {
+	return g.ballistics.terrain.height(targetRange)
+}
+
+// getTerrainText draws the terrain under the impact path, from the lowest "_" to the highest "^" point.
+func (g *Game) getTerrainText() string {
+	const levels = "_.-=^"
+	low, high := g.ballistics.terrain.span()
+	text := make([]byte, g.width)
+	for i := range text {
+		h := g.ballistics.terrain.height(float64(i) / float64(g.width-1) * g.maxRange)
+		level := len(levels) / 2
+		if high > low {
+			level = int((h - low) / (high - low) * float64(len(levels)-1))
+		}
+		text[i] = levels[level]
+	}
+	return string(text)
+}
+
+// printImpactResults reports a shot against the target that it was measured against, and whether the
+// game is over. Every target within the detonation radius is destroyed.
+func (g *Game) printImpactResults(shot shotResult, shotCount int) bool {
+	target := shot.Target - 1
+	fmt.Fprintf(g.out, "%s Range = %s at time of impact.\n", strings.Title(g.getTargetName(target)), g.getDisplayText(g.targets[target].distance))
+	if math.Hypot(shot.Delta, shot.Lateral) <= g.config.DeathRadius {
+		hits := []int{target}
+		g.targets[target].destroyed = true
+		for i, t := range g.targets {
+			if !t.destroyed && math.Hypot(g.getMiss(i, shot.Range, shot.Azimuth)) <= g.config.DeathRadius {
+				g.targets[i].destroyed = true
+				hits = append(hits, i)
+			}
+		}
+		g.printImpactTimeline(shot, true)
+		fmt.Fprintln(g.out, "")
+		left := g.targetsLeft()
+		for _, i := range hits {
+			delta, lateral := g.getMiss(i, shot.Range, shot.Azimuth)
+			if i == target {
+				delta, lateral = shot.Delta, shot.Lateral
+			}
+			message := fmt.Sprintf("Direct hit (within %s) after %d shots!!", g.getDisplayText(math.Hypot(delta, lateral)), shotCount)
+			if len(g.targets) > 1 || g.duel != nil {
+				message = fmt.Sprintf("Direct hit on %s (within %s) after %d shots!", g.getTargetName(i), g.getDisplayText(math.Hypot(delta, lateral)), shotCount)
+			}
+			fmt.Fprintln(g.out, g.paint(colorHitText, message))
+			g.emit("hit", &hitEvent{Shot: shotCount, Delta: delta, Target: i + 1, Left: left})
+		}
+		if left == 0 {
+			if len(g.targets) > 1 {
+				fmt.Fprintf(g.out, "All %d targets destroyed after %d shots!!\n", len(g.targets), shotCount)
+			}
+			fmt.Fprintln(g.out, "")
+			g.outcome = outcomeHit
+			return true
+		}
+		fmt.Fprintf(g.out, "%d of %d targets left.\n", left, len(g.targets))
+		fmt.Fprintln(g.out, "")
+		return g.isAnyGameOverMan()
+	} else if g.isAnyGameOverMan() {
+		return true
+	} else {
+		lateral := ""
+		if g.config.TwoD && math.Abs(shot.Lateral) >= 0.05 {
+			lateral = fmt.Sprintf(" and missed %s to the %s", g.getDisplayText(math.Abs(shot.Lateral)), getLeftOrRightText(shot.Lateral))
+		}
+		if shot.Delta > 0.0 {
+			fmt.Fprintln(g.out, g.paint(colorShort, fmt.Sprintf("<< Undershot %s by %s%s.", g.getTargetName(target), g.getDisplayText(-shot.Delta), lateral)))
+		} else {
+			fmt.Fprintln(g.out, g.paint(colorLong, fmt.Sprintf(">> Overshot %s by %s%s.", g.getTargetName(target), g.getDisplayText(-shot.Delta), lateral)))
+		}
+		g.printImpactTimeline(shot, false)
+	}
+	return false
+}
+
+// getLeftOrRightText tells on which side of a target a shot landed, from the lateral miss of getMiss.
+func getLeftOrRightText(lateral float64) string {
+	if lateral > 0.0 {
+		return "left"
+	}
+	return "right"
+}
+
+// isAnyGameOverMan reports whether any target that is left reached the tank.
+func (g *Game) isAnyGameOverMan() bool {
+	for _, t := range g.targets {
+		if !t.destroyed && g.isGameOverMan(t.distance) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Game) isGameOverMan(targetRange float64) bool {
+	if targetRange <= g.config.DeathRadius {
+		fmt.Fprintln(g.out, "")
+		fmt.Fprintln(g.out, gameOverMan)
+		fmt.Fprintln(g.out, "")
+		g.outcome = outcomeCrushed
+		g.emit("crushed", &crushedEvent{TargetRange: targetRange})
+		return true
+	}
+	return false
+}
+
+// takeShot fires the projectile at shotAzimuth and waits for it to land, then measures the shot
+// against the target that is nearest to where it landed. If ctx is cancelled during the flight,
+// takeShot returns early and the caller is expected to check ctx.Err().
+func (g *Game) takeShot(ctx context.Context, shotCount int, shotAngle, shotAzimuth float64) (shot shotResult) {
+	shot.Angle, shot.Azimuth = shotAngle, shotAzimuth
+	shot.Range, shot.FlightTime = g.ballistics.flight(shotAngle, g.projectileVmps)
+	azimuth := ""
+	if g.config.TwoD {
+		azimuth = fmt.Sprintf(" and %4.2f degrees azimuth", shotAzimuth)
+	}
+	fmt.Fprintf(g.out, "Taking shot #%d at %4.2f degrees%s. Flight time is %3.1f seconds.\n", shotCount, shotAngle, azimuth, shot.FlightTime)
+	g.emit("shot_fired", &shotFiredEvent{Shot: shotCount, Angle: shotAngle, Azimuth: shotAzimuth, Range: shot.Range, FlightTime: shot.FlightTime, Wind: g.ballistics.wind})
+	// Wait here so that the target has time to move in targetMovement() during the shot.
+	if g.clock.Sleep(ctx, seconds(shot.FlightTime)) != nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.targetModeAuto {
+		// Fast forward the targets to the correct location.
+		if g.moveTargets(shot.FlightTime) {
+			g.gameOver = true
+			return
+		}
+	}
+	fmt.Fprintf(g.out, "Shot #%d took %3.1f seconds, and went %s (%3.1f %s).\n", shotCount, shot.FlightTime, g.getDisplayText(shot.Range), getMilesOrKilometers(shot.Range, g.config.EnglishUnits), milesOrKilometers[g.config.EnglishUnits])
+	target := g.nearestTarget(shot.Range, shot.Azimuth)
+	shot.Target = target + 1
+	shot.Delta, shot.Lateral = g.getMiss(target, shot.Range, shot.Azimuth)
+	shot.TargetRange = g.targets[target].distance
+	now := g.clock.Now().Seconds()
+	for i, t := range g.targets {
+		if !t.destroyed && t.behavior != nil {
+			delta, lateral := g.getMiss(i, shot.Range, shot.Azimuth)
+			t.behavior.shotLanded(now, math.Copysign(math.Hypot(delta, lateral), delta))
+		}
+	}
+	g.emit("shot_impact", &shotImpactEvent{Shot: shotCount, Angle: shotAngle, Azimuth: shotAzimuth, Range: shot.Range, FlightTime: shot.FlightTime, Delta: shot.Delta, Lateral: shot.Lateral, TargetRange: shot.TargetRange, Target: shot.Target})
+	return
+}
+
+// moveTargets moves every target that is left closer to the tank for the elapsed seconds up to now,
+// firing back on the way, and reports whether the enemy shells destroyed the tank.
+func (g *Game) moveTargets(elapsed float64) bool {
+	start := g.clock.Now().Seconds() - elapsed
+	for i := range g.targets {
+		t := &g.targets[i]
+		if t.destroyed {
+			continue
+		}
+		from, left := start, elapsed
+		for t.gun != nil && t.gun.nextShot <= start+elapsed {
+			step := t.gun.nextShot - from
+			t.distance -= t.travel(from, step)
+			from, left = t.gun.nextShot, left-step
+			g.enemyFire(i, from)
+		}
+		t.distance -= t.travel(from, left)
+		g.emit("target_moved", &targetMovedEvent{TargetRange: t.distance, Target: i + 1})
+	}
+	return g.landShells(start + elapsed)
+}
+
+// newClock returns the clock for the target mode of the game.
+func (g *Game) newClock() Clock {
+	if g.targetModeAuto {
+		// Both the battle manager and the target movement sleep on the clock.
+		return newClock(g.targetSpeedMultiplier, 2)
+	}
+	// The target pauses, so shots land as soon as they are fired.
+	return newInstantClock(1)
+}
+
+// observe returns what the player knows before the next shot.
+func (g *Game) observe() observation {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	closest := g.closestTarget()
+	now := g.clock.Now().Seconds()
+	return observation{
+		ProjectileVmps: g.projectileVmps,
+		MaxRange:       g.maxRange,
+		Target:         closest + 1,
+		TargetRange:    g.targets[closest].distance,
+		TargetVmps:     g.targets[closest].vmps * g.targets[closest].factorAt(now),
+		TargetBearing:  g.targets[closest].bearing,
+		Targets:        g.targetStates(),
+		Wind:           g.ballistics.wind,
+		HighAngle:      g.config.HighAngle,
+		Time:           now,
+		Shots:          append([]shotResult(nil), g.shots...),
+		ballistics:     g.ballistics,
+	}
+}
+
+func (g *Game) battleManager(ctx context.Context) {
+	shotAngle, shotAzimuth := 0.0, 0.0
+	shotCount := 0
+	for {
+		g.printHeader()
+		if g.replay != nil {
+			shot := g.nextReplayShot(ctx, shotCount)
+			shotAngle, shotAzimuth = shot.Angle, shot.Azimuth
+		} else if g.config.ShootModeAuto {
+			obs := g.observe()
+			shotAngle = g.strategy.nextAngle(obs)
+			shotAngle = math.Max(math.Min(shotAngle, g.maxAngle()), minShotAngle)
+			// The strategies only choose the angle, the azimuth points straight at the target.
+			shotAzimuth = obs.TargetBearing
+		} else {
+			g.emit("awaiting_shot", &awaitingShotEvent{Shot: shotCount + 1, Wind: g.ballistics.wind})
+			g.clock.Await(func() {
+				if g.config.TwoD {
+					shotAngle, shotAzimuth = getNextShotAim(ctx, g.lines, g.out, g.maxAngle())
+				} else {
+					shotAngle = getNextShotAngle(ctx, g.lines, g.out, g.maxAngle())
+				}
+			})
+		}
+		if shotAngle == 0.0 {
+			if ctx.Err() == nil {
+				g.mu.Lock()
+				g.outcome = outcomeQuit
+				g.emit("quit", &quitEvent{Shots: shotCount})
+				g.mu.Unlock()
+			}
+			return
+		}
+		shotCount++
+		firedAt := g.clock.Now().Seconds()
+		g.mu.Lock()
+		g.recordedShots = append(g.recordedShots, recordedShot{Angle: shotAngle, Azimuth: shotAzimuth, FiredAt: firedAt})
+		g.mu.Unlock()
+		shot := g.takeShot(ctx, shotCount, shotAngle, shotAzimuth)
+		if ctx.Err() != nil {
+			return
+		}
+
+		g.mu.Lock()
+		if !g.gameOver {
+			g.gameOver = g.printImpactResults(shot, shotCount)
+			shot.FiredAt = firedAt
+			g.shots = append(g.shots, shot)
+			if !g.gameOver && g.config.MaxShots > 0 && shotCount >= g.config.MaxShots {
+				fmt.Fprintf(g.out, "Out of ammunition after %d shots!\n", shotCount)
+				g.gameOver = true
+				g.outcome = outcomeOutOfShots
+				g.emit("out_of_ammo", &outOfAmmoEvent{Shots: shotCount})
+			}
+		}
+		gameOver := g.gameOver
+		g.mu.Unlock()
+		if gameOver {
+			return
+		}
+		g.driftWind()
+	}
+}
+
+func (g *Game) setWind(windKph float64) {
+	g.windKph = windKph
+	g.ballistics.wind = windKph * (metersPerKilometer / secondsPerHour)
+}
+
+// driftWind randomly changes the wind for the next shot, staying within WindGust of Wind. A live display
+// draws the wind in the header meanwhile.
+func (g *Game) driftWind() {
+	gust := g.config.WindGust
+	if gust <= 0.0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	wind := g.windKph + getRandomValue(g.rand, -gust/2.0, gust/2.0)
+	g.setWind(math.Max(math.Min(wind, g.config.Wind+gust), g.config.Wind-gust))
+}
+
+func getWindText(windKph float64) string {
+	if windKph < 0.0 {
+		return "head wind"
+	}
+	return "tail wind"
+}
+
+func (g *Game) targetMovement(ctx context.Context) {
+	movementCount := 0
+	for {
+		if g.clock.Sleep(ctx, time.Second) != nil {
+			return
+		}
+
+		g.mu.Lock()
+		if g.gameOver {
+			g.mu.Unlock()
+			return
+		}
+		destroyed := g.moveTargets(1.0)
+		movementCount++
+		if (movementCount % 10) == 0 {
+			note := ""
+			if math.IsInf(g.targetSpeedMultiplier, 1) {
+				note = " (simulated instantly)"
+			} else if g.targetSpeedMultiplier != 1 {
+				note = fmt.Sprintf(" (at %s)", getSpeedText(g.targetSpeedMultiplier))
+			}
+			for i, t := range g.targets {
+				if !t.destroyed {
+					fmt.Fprintf(g.out, "%s Range = %s after %d seconds%s.\n", strings.Title(g.getTargetName(i)), g.getDisplayText(t.distance), 10, note)
+				}
+			}
+		}
+		g.gameOver = destroyed || g.isAnyGameOverMan()
+		gameOver := g.gameOver
+		g.mu.Unlock()
+		if gameOver {
+			return
+		}
+	}
+}
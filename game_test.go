@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withDefaults fills in the options that the tests leave out with their defaults.
+func withDefaults(config Config) Config {
+	defaults := defaultConfig()
+	config.MinProjectileVmps, config.MaxProjectileVmps = defaults.MinProjectileVmps, defaults.MaxProjectileVmps
+	config.MinTargetVkph, config.MaxTargetVkph = defaults.MinTargetVkph, defaults.MaxTargetVkph
+	config.MinStartRange, config.MaxStartRange = defaults.MinStartRange, defaults.MaxStartRange
+	return config
+}
+
+func TestGame_Run(t *testing.T) {
+	type args struct {
+		config      Config
+		input       string
+		targetRange float64
+		targetVmps  float64
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantOutcome outcome
+	}{
+		{
+			name:        "Auto Shot - Realtime Target",
+			args:        args{withDefaults(Config{ShootModeAuto: true, DeathRadius: impactRadius, Speed: "instant", Seed: 1}), "", 0.0, 0.0},
+			wantOutcome: outcomeHit,
+		},
+		{
+			name:        "Manual Shot - Realtime Target crushes a waiting player",
+			args:        args{withDefaults(Config{TargetModeAuto: true, DeathRadius: impactRadius, Speed: "1000"}), "", 100.0, 50.0},
+			wantOutcome: outcomeCrushed,
+		},
+		{
+			name:        "Manual Shot - Returned Fire destroys a waiting player",
+			args:        args{withDefaults(Config{TargetModeAuto: true, DeathRadius: impactRadius, Speed: "1000", Seed: 1, ReturnFire: true, HitPoints: 1}), "", 5000.0, 0.0},
+			wantOutcome: outcomeDestroyed,
+		},
+		{
+			name:        "Manual Shot - Realtime Target with a quitting player",
+			args:        args{withDefaults(Config{TargetModeAuto: true, DeathRadius: impactRadius, Speed: "1000"}), "0\n", 0.0, 0.0},
+			wantOutcome: outcomeQuit,
+		},
+		{
+			name:        "Auto Shot - Three Targets",
+			args:        args{withDefaults(Config{ShootModeAuto: true, DeathRadius: impactRadius, Speed: "instant", Seed: 1, Targets: 3}), "", 0.0, 0.0},
+			wantOutcome: outcomeHit,
+		},
+		{
+			name:        "Auto Shot - 2D Battlefield",
+			args:        args{withDefaults(Config{ShootModeAuto: true, DeathRadius: impactRadius, Speed: "instant", Seed: 1, Targets: 2, TwoD: true}), "", 0.0, 0.0},
+			wantOutcome: outcomeHit,
+		},
+		{
+			name:        "Auto Shot - Out of Ammunition",
+			args:        args{withDefaults(Config{ShootModeAuto: true, Strategy: "bisect", DeathRadius: impactRadius, Speed: "instant", Seed: 1, MaxShots: 1}), "", 0.0, 0.0},
+			wantOutcome: outcomeOutOfShots,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// An empty input blocks forever, like a player that never answers the prompt.
+			var input io.Reader = strings.NewReader(tt.args.input)
+			if tt.args.input == "" {
+				pr, pw := io.Pipe()
+				defer pw.Close()
+				input = pr
+			}
+			g, err := NewGame(tt.args.config, input, io.Discard)
+			if err != nil {
+				t.Fatalf("NewGame() error = %v", err)
+			}
+			if tt.args.targetRange > 0.0 {
+				g.targets[0].distance = tt.args.targetRange
+				g.targets[0].vmps = tt.args.targetVmps
+			}
+
+			done := make(chan struct{})
+			go func() {
+				g.Run(context.Background())
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("Run() did not return")
+			}
+			if wantGameOver := tt.args.input == ""; g.gameOver != wantGameOver {
+				t.Errorf("Run() gameOver = %v, want %v", g.gameOver, wantGameOver)
+			}
+			if got := g.result().Outcome; got != tt.wantOutcome {
+				t.Errorf("Run() outcome = %q, want %q", got, tt.wantOutcome)
+			}
+		})
+	}
+}
+
+func TestGame_targetMovement(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	g, err := NewGame(withDefaults(Config{TargetModeAuto: true, DeathRadius: impactRadius}), pr, io.Discard)
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	clock := newManualClock()
+	g.clock = clock
+	g.targets[0].distance = 1000.0
+	g.targets[0].vmps = 10.0
+
+	done := make(chan struct{})
+	go func() {
+		g.Run(context.Background())
+		close(done)
+	}()
+
+	// The player never answers, so the target closes in one step per simulated second.
+	for seconds := 1; seconds <= 98; seconds++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second)
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run() did not return")
+	}
+	if g.targets[0].distance != 20.0 || !g.gameOver {
+		t.Errorf("Run() targetRange = %v, gameOver = %v, want 20, true", g.targets[0].distance, g.gameOver)
+	}
+	if got := clock.Now(); got != 98*time.Second {
+		t.Errorf("clock.Now() = %v, want %v", got, 98*time.Second)
+	}
+}
+
+func TestNewGame_seed(t *testing.T) {
+	newGame := func(seed int64) *Game {
+		g, err := NewGame(withDefaults(Config{Seed: seed, DeathRadius: impactRadius}), strings.NewReader(""), io.Discard)
+		if err != nil {
+			t.Fatalf("NewGame() error = %v", err)
+		}
+		return g
+	}
+	g1, g2, g3 := newGame(42), newGame(42), newGame(43)
+	if g1.projectileVmps != g2.projectileVmps || g1.targets[0] != g2.targets[0] {
+		t.Errorf("NewGame() with the same seed created different scenarios")
+	}
+	if g1.projectileVmps == g3.projectileVmps && g1.targets[0] == g3.targets[0] {
+		t.Errorf("NewGame() with different seeds created the same scenario")
+	}
+	// 0 is a seed like any other, it replays the same scenario.
+	if g0, g := newGame(0), newGame(0); g0.seed != 0 || g0.projectileVmps != g.projectileVmps || g0.targets[0] != g.targets[0] {
+		t.Errorf("NewGame() with seed 0 = seed %d, want the same scenario every time", g0.seed)
+	}
+}
+
+func TestGame_driftWind(t *testing.T) {
+	g, err := NewGame(withDefaults(Config{Seed: 7, DeathRadius: impactRadius, Wind: -20.0, WindGust: 10.0}), strings.NewReader(""), io.Discard)
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	if g.windKph != -20.0 {
+		t.Errorf("NewGame() windKph = %v, want %v", g.windKph, -20.0)
+	}
+	changed := false
+	for shot := 0; shot < 100; shot++ {
+		g.driftWind()
+		if g.windKph < -30.0 || g.windKph > -10.0 {
+			t.Fatalf("driftWind() windKph = %v, want within 10 of -20", g.windKph)
+		}
+		if g.ballistics.wind != g.windKph*(metersPerKilometer/secondsPerHour) {
+			t.Fatalf("driftWind() ballistics wind = %v, want %v kph", g.ballistics.wind, g.windKph)
+		}
+		changed = changed || g.windKph != -20.0
+	}
+	if !changed {
+		t.Errorf("driftWind() never changed the wind")
+	}
+}
+
+func TestGame_driftWind_live(t *testing.T) {
+	g, err := NewGame(withDefaults(Config{Seed: 7, DeathRadius: impactRadius, WindGust: 10.0}), strings.NewReader(""), io.Discard)
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	g.clock = g.newClock()
+	// A live display draws the header while the wind drifts between the shots.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for shot := 0; shot < 100; shot++ {
+			g.driftWind()
+		}
+	}()
+	for drawn := false; !drawn; {
+		select {
+		case <-done:
+			drawn = true
+		default:
+		}
+		g.mu.Lock()
+		g.writeHeader(io.Discard)
+		g.mu.Unlock()
+		g.observe()
+	}
+}
+
+func TestNewGame_fixedValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(config *Config)
+		wantErr bool
+	}{
+		{
+			name: "Fixed",
+			change: func(config *Config) {
+				config.MinProjectileVmps, config.MaxProjectileVmps = 450.0, 450.0
+				config.MinTargetVkph, config.MaxTargetVkph = 36.0, 36.0
+				config.MinStartMeters, config.MaxStartMeters = 5000.0, 5000.0
+			},
+		},
+		{
+			name: "Start Range beyond the Max Range of the slowest Projectile",
+			change: func(config *Config) {
+				config.MinStartMeters, config.MaxStartMeters = 10000.0, 10000.0
+			},
+			wantErr: true,
+		},
+		{
+			name: "Min Start Range beyond the Max Start Range",
+			change: func(config *Config) {
+				config.MinProjectileVmps, config.MaxProjectileVmps = 300.0, 300.0
+				config.MinStartMeters = 5000.0
+				config.MaxStartRange = 0.5
+			},
+			wantErr: true,
+		},
+		{
+			name: "Min Start Range beyond the Max Start Range of the slowest Projectile, with a fast one",
+			change: func(config *Config) {
+				difficulties["hard"](config)
+				config.MinStartMeters = 8000.0
+				config.Seed = 1 // draws a projectile that reaches 8000 meters at 80% of its max range
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := defaultConfig()
+			tt.change(&config)
+			g, err := NewGame(config, strings.NewReader(""), io.Discard)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewGame() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if g.projectileVmps != 450.0 || g.targets[0].vmps != 10.0 || g.targets[0].distance != 5000.0 {
+				t.Errorf("NewGame() = %v meters/sec, %v meters/sec, %v meters, want 450, 10, 5000", g.projectileVmps, g.targets[0].vmps, g.targets[0].distance)
+			}
+		})
+	}
+}
+
+func TestNewGame_loneStartRange(t *testing.T) {
+	config := defaultConfig()
+	var b ballistics
+	slowestMax := b.maxRange(config.MinProjectileVmps) * config.MaxStartRange
+	fastestMin := b.maxRange(config.MaxProjectileVmps) * config.MinStartRange
+	tests := []struct {
+		name    string
+		min     float64
+		max     float64
+		wantErr bool
+	}{
+		{name: "Min at the Max Start Range of the slowest Projectile", min: slowestMax},
+		{name: "Min beyond the Max Start Range of the slowest Projectile", min: slowestMax + 1.0, wantErr: true},
+		{name: "Max at the Min Start Range of the fastest Projectile", max: fastestMin},
+		{name: "Max below the Min Start Range of the fastest Projectile", max: 5000.0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every seed starts the game, or none does.
+			for seed := int64(1); seed <= 50; seed++ {
+				config := defaultConfig()
+				config.MinStartMeters, config.MaxStartMeters, config.Seed = tt.min, tt.max, seed
+				if _, err := NewGame(config, strings.NewReader(""), io.Discard); (err != nil) != tt.wantErr {
+					t.Fatalf("NewGame() seed %d error = %v, wantErr %v", seed, err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestNewGame_targets(t *testing.T) {
+	single, err := NewGame(withDefaults(Config{Seed: 42, DeathRadius: impactRadius}), strings.NewReader(""), io.Discard)
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	g, err := NewGame(withDefaults(Config{Seed: 42, DeathRadius: impactRadius, Targets: 3}), strings.NewReader(""), io.Discard)
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	if len(g.targets) != 3 {
+		t.Fatalf("NewGame() targets = %d, want 3", len(g.targets))
+	}
+	// The first target is the same as in a single target game with the same seed.
+	if g.targets[0] != single.targets[0] || g.projectileVmps != single.projectileVmps {
+		t.Errorf("NewGame() first target = %+v, want %+v", g.targets[0], single.targets[0])
+	}
+	minStart, maxStart := g.getStartRange()
+	for i, target := range g.targets {
+		if target.distance < minStart || target.distance > maxStart {
+			t.Errorf("NewGame() target #%d range = %v, want from %v to %v", i+1, target.distance, minStart, maxStart)
+		}
+	}
+}
+
+func TestGame_printImpactResults_targets(t *testing.T) {
+	type args struct {
+		targets   []float64
+		shotRange float64
+		target    int
+	}
+	tests := []struct {
+		name        string
+		args        args
+		want        bool
+		wantOutcome outcome
+		wantLeft    int
+	}{
+		{
+			name:     "One of two destroyed",
+			args:     args{[]float64{500.0, 800.0}, 505.0, 0},
+			want:     false,
+			wantLeft: 1,
+		},
+		{
+			name:        "Both destroyed by the same shot",
+			args:        args{[]float64{500.0, 520.0}, 505.0, 0},
+			want:        true,
+			wantOutcome: outcomeHit,
+			wantLeft:    0,
+		},
+		{
+			name:        "The other target reached the tank",
+			args:        args{[]float64{500.0, 10.0}, 505.0, 0},
+			want:        true,
+			wantOutcome: outcomeCrushed,
+			wantLeft:    1,
+		},
+		{
+			name:     "Miss",
+			args:     args{[]float64{500.0, 800.0}, 600.0, 0},
+			want:     false,
+			wantLeft: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Game{config: Config{DeathRadius: impactRadius}, out: io.Discard, maxRange: 1000.0}
+			g.setTimelineWidth(defaultTimelineWidth)
+			for _, distance := range tt.args.targets {
+				g.targets = append(g.targets, target{distance: distance})
+			}
+			shot := shotResult{Range: tt.args.shotRange, Target: tt.args.target + 1, Delta: g.targets[tt.args.target].distance - tt.args.shotRange}
+			if got := g.printImpactResults(shot, 1); got != tt.want {
+				t.Errorf("printImpactResults() = %v, want %v", got, tt.want)
+			}
+			if g.outcome != tt.wantOutcome || g.targetsLeft() != tt.wantLeft {
+				t.Errorf("printImpactResults() outcome = %q, left = %d, want %q, %d", g.outcome, g.targetsLeft(), tt.wantOutcome, tt.wantLeft)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"io"
@@ -10,8 +11,6 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"sync"
-	"time"
 )
 
 // -----------------------------------------------------------
@@ -25,116 +24,128 @@ const (
 	maxProjectileVmps  = 600  // meters/sec
 	minTargetVkph      = 0    // kilometers/hour
 	maxTargetVkph      = 60   // kilometers/hour
+	minStartRange      = 0.2  // of the max range
+	maxStartRange      = 1.0  // of the max range
 	impactRadius       = 20.0 // meters
 	minShotAngle       = 1.0  // degrees
 	maxShotAngle       = 45.0 // degrees
+	maxHighShotAngle   = 89.0 // degrees, with high-angle fire
 	metersPerKilometer = 1000.0
 	feetPerMile        = 5280.0
 	feetPerMeter       = 3.28084
 	secondsPerHour     = 60.0 * 60.0
 	gravityAmps2       = 9.80665
 
-	flightPath = " /~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~"
-	impactPath = "/--------+---------+---------+---------+---------|"
-	// Ruler      01234567890123456789012345678901234567890123456789
-	gameOverMan = "GAME OVER MAN, you just got crushed by the other tank!"
+	minShotIndex   = 4 // on the impact path, leaves room for the flight path to rise from the tank
+	minTargetIndex = 5 // on the impact path, for a target beyond a shot at minShotIndex
+
+	gameOverMan       = "GAME OVER MAN, you just got crushed by the other tank!"
+	gameOverDestroyed = "GAME OVER MAN, the other tank just blew you to pieces!"
 )
 
 var (
-	projectileVmps        float64
-	targetVkph            float64
-	targetVmps            float64
-	maxRange              float64
-	targetRange           float64
-	deathRadius           float64
-	wg                    sync.WaitGroup
-	shootModeAuto         bool = false // true = Auto Shoot Mode, false = Manual Shot
-	targetModeAuto        bool = false // true = target moves when deciding shot, false = target pauses when deciding shot
-	targetSpeedMultiplier int          // times faster than real-time
-	englishUnits          bool = false // true = english units, false = metric units
-	printShotProfile      bool = false // true = print the shot profile on startup, false = don't
-	rulerText             string
-
 	englishOrMetric   = map[bool]string{true: "English", false: "Metric"}
 	feetOrMeters      = map[bool]string{true: "feet", false: "meters"}
 	milesOrKilometers = map[bool]string{true: "miles", false: "kilometers"}
 )
 
-func parseFlags() {
-	flag.BoolVar(&shootModeAuto, "a", shootModeAuto, "Auto Shot Mode (default - Manual Shot)")
-	flag.BoolVar(&targetModeAuto, "m", targetModeAuto, "Real-time Target Movement (default - Pause Target During Shot Decision)")
-	flag.BoolVar(&englishUnits, "e", englishUnits, "English Units (default - Metric)")
-	flag.BoolVar(&printShotProfile, "p", printShotProfile, "Print Shot Profile")
-	flag.Float64Var(&deathRadius, "d", impactRadius, "Detonation Radius (meters)")
-	flag.Parse()
+// outputOptions choose where the game output goes, they don't change the game itself.
+type outputOptions struct {
+	JSON   bool   // true = the event stream replaces the text on stdout
+	Events string // file to write the event stream to, "" = none
+	Record string // file to save the recording of the game to, "" = none
+	TUI    bool   // true = the full-screen display replaces the scrolling text on stdout
+	Color  string // auto, always or never
+	Width  int    // characters of the timeline, 0 = as wide as the terminal
 }
 
-func getTargetMode(shootModeAuto, targetModeAutoDefault bool) (targetModeAuto bool, targetSpeedMultiplier int) {
-	targetModeAuto = targetModeAutoDefault
-	if shootModeAuto {
-		fmt.Println("Shot Mode: Auto")
-		targetSpeedMultiplier = 10
-		targetModeAuto = true
-	} else {
-		fmt.Println("Shot Mode: Manual")
-		targetSpeedMultiplier = 1
-		if targetModeAuto {
-			fmt.Println("Target Mode: Realtime Target Movement")
-		} else {
-			fmt.Println("Target Mode: Pause Target During Shot Decision")
-		}
+func parseFlags() (config Config, output outputOptions, err error) {
+	configFlags := newConfigFlags(flag.CommandLine)
+	addOutputFlags(flag.CommandLine, &output)
+	flag.Parse()
+	if err = output.validate(); err != nil {
+		return
 	}
+	config, err = configFlags.resolve()
 	return
 }
 
-func getRandomValue(min, max float64) float64 {
-	return min + float64(rand.Intn(10000))*float64(max-min)/10000.0
+// addOutputFlags defines the flags that choose where the output of a game goes on fs.
+func addOutputFlags(fs *flag.FlagSet, output *outputOptions) {
+	fs.BoolVar(&output.JSON, "json", false, "Write the Game Events as JSON lines to stdout instead of the text display")
+	fs.StringVar(&output.Events, "events", "", "Write the Game Events as JSON lines to this file")
+	fs.StringVar(&output.Record, "record", "", "Save the Game to this file, to watch it again with \"tank replay\"")
+	fs.BoolVar(&output.TUI, "tui", false, "Full-Screen Display with a live Timeline (default - Scrolling Text)")
+	fs.IntVar(&output.Width, "width", 0, fmt.Sprintf("Width of the Timeline (characters), at least %d (default - as wide as the Terminal, or %d when not on a Terminal)", minTimelineWidth, defaultTimelineWidth))
+	fs.StringVar(&output.Color, "color", colorModes[0], "Colors in the Display: "+strings.Join(colorModes, ", ")+" (auto - on a Terminal unless $NO_COLOR is set)")
 }
 
-func displayShotProfile() {
-	fmt.Println("")
-	fmt.Println("Shot Profile:")
-	fmt.Printf("+-------+------------+-------+\n")
-	fmt.Printf("| Angle | Shot Range | Time  |\n")
-	fmt.Printf("| (deg) | %10s | (sec) |\n", "("+feetOrMeters[englishUnits]+")")
-	fmt.Printf("+-------+------------+-------+\n")
-	for angle := minShotAngle; angle <= maxShotAngle; angle += 1.0 {
-		shotRange, shotTime := xRange(angle, projectileVmps)
-		fmt.Printf("| %5.1f | %10.1f | %5.1f |\n", angle, getFeetOrMeters(shotRange, englishUnits), shotTime)
+func (o outputOptions) validate() error {
+	if o.TUI && o.JSON {
+		return fmt.Errorf("invalid flags: -tui can't be used with -json")
+	}
+	if _, err := getColorEnabled(o.Color, false, false, false); err != nil {
+		return err
 	}
-	fmt.Printf("+-------+------------+-------+\n")
-	fmt.Println("")
+	return validateTimelineWidth(o.Width)
 }
 
-func initialize() {
-	parseFlags()
-	targetModeAuto, targetSpeedMultiplier = getTargetMode(shootModeAuto, targetModeAuto)
-
-	fmt.Printf("Units: %s\n", englishOrMetric[englishUnits])
-	fmt.Printf("Detonation Radius = %s\n", getDisplayText(deathRadius))
-
-	// Initialize random values.
-	rand.Seed(time.Now().UnixNano())
-	projectileVmps = getRandomValue(minProjectileVmps, maxProjectileVmps)
-	targetVkph = getRandomValue(minTargetVkph, maxTargetVkph)
-	targetVmps = targetVkph * (metersPerKilometer / secondsPerHour)
-	maxRange, _ = xRange(maxShotAngle, projectileVmps)
-	targetRange = getRandomValue(maxRange*0.2, maxRange)
+// colorEnabled reports whether the display on stdout is painted.
+func (o outputOptions) colorEnabled() bool {
+	color, _ := getColorEnabled(o.Color, os.Getenv("NO_COLOR") != "", os.Getenv("TERM") == "dumb", isTerminal(os.Stdout))
+	return color
+}
 
-	// Calculate distance markers for the legend undel the timeline.
-	rulerText = fmt.Sprintf("       %5s     %5s     %5s     %5s     %5s",
-		getRulerText(1.0*maxRange/5.0),
-		getRulerText(2.0*maxRange/5.0),
-		getRulerText(3.0*maxRange/5.0),
-		getRulerText(4.0*maxRange/5.0),
-		getRulerText(5.0*maxRange/5.0),
-	)
-	rulerText = rulerText[:len(rulerText)-1] + strings.Title(milesOrKilometers[englishUnits])
+// addGameFlags defines the flags that configure a game on fs, with the values in config as defaults.
+func addGameFlags(fs *flag.FlagSet, config *Config) {
+	fs.BoolVar(&config.ShootModeAuto, "a", config.ShootModeAuto, "Auto Shot Mode (default - Manual Shot)")
+	fs.BoolVar(&config.TargetModeAuto, "m", config.TargetModeAuto, "Real-time Target Movement (default - Pause Target During Shot Decision)")
+	fs.BoolVar(&config.EnglishUnits, "e", config.EnglishUnits, "English Units (default - Metric)")
+	fs.BoolVar(&config.PrintShotProfile, "p", config.PrintShotProfile, "Print Shot Profile")
+	fs.BoolVar(&config.HighAngle, "high", config.HighAngle, "High-Angle (Plunging) Fire up to 89 degrees, Auto Shot Mode uses the High Arc")
+	fs.Float64Var(&config.DeathRadius, "d", config.DeathRadius, "Detonation Radius (meters)")
+	fs.BoolVar(&config.Drag, "drag", config.Drag, "Air Drag on the Projectile (default - Vacuum)")
+	fs.Float64Var(&config.DragCoefficient, "cd", config.DragCoefficient, "Drag Coefficient of the Projectile, with -drag")
+	fs.Float64Var(&config.ProjectileMass, "mass", config.ProjectileMass, "Projectile Mass (kilograms), with -drag")
+	fs.Float64Var(&config.Caliber, "caliber", config.Caliber, "Projectile Caliber (millimeters), with -drag")
+	fs.Float64Var(&config.Wind, "wind", config.Wind, "Wind Velocity (kilometers/hour), + = Tail Wind, - = Head Wind")
+	fs.Float64Var(&config.WindGust, "gust", config.WindGust, "Wind Gusts (kilometers/hour), the Wind drifts randomly up to this much from -wind every shot")
+	fs.Float64Var(&config.Elevation, "elevation", config.Elevation, "Target Elevation (meters) above (+) or below (-) the Tank")
+	fs.Float64Var(&config.Terrain, "terrain", config.Terrain, "Terrain Roughness (meters), Rolling Hills up to this much above and below -elevation (default - Flat)")
+	fs.Var(&exactValue{min: &config.MinProjectileVmps, max: &config.MaxProjectileVmps}, "velocity", "Projectile Velocity (meters/sec) (default - random from -min-velocity to -max-velocity)")
+	fs.Float64Var(&config.MinProjectileVmps, "min-velocity", config.MinProjectileVmps, "Minimum Random Projectile Velocity (meters/sec)")
+	fs.Float64Var(&config.MaxProjectileVmps, "max-velocity", config.MaxProjectileVmps, "Maximum Random Projectile Velocity (meters/sec)")
+	fs.Var(&exactValue{min: &config.MinTargetVkph, max: &config.MaxTargetVkph}, "target-velocity", "Target Velocity (kilometers/hour) (default - random from -min-target-velocity to -max-target-velocity)")
+	fs.Float64Var(&config.MinTargetVkph, "min-target-velocity", config.MinTargetVkph, "Minimum Random Target Velocity (kilometers/hour)")
+	fs.Float64Var(&config.MaxTargetVkph, "max-target-velocity", config.MaxTargetVkph, "Maximum Random Target Velocity (kilometers/hour)")
+	fs.Var(&exactValue{min: &config.MinStartMeters, max: &config.MaxStartMeters}, "range", "Starting Target Range (meters) (default - random from -min-range to -max-range)")
+	fs.Float64Var(&config.MinStartMeters, "min-range", config.MinStartMeters, "Minimum Random Starting Target Range (meters) (default - 20% of the Max Projectile Range)")
+	fs.Float64Var(&config.MaxStartMeters, "max-range", config.MaxStartMeters, "Maximum Random Starting Target Range (meters) (default - the Max Projectile Range)")
+	fs.IntVar(&config.Targets, "targets", config.Targets, "Number of Targets closing in at the same time, the game is won when all of them are destroyed")
+	fs.StringVar(&config.TargetBehavior, "behavior", config.TargetBehavior, "Target Behavior: "+getBehaviorNames())
+	fs.BoolVar(&config.ReturnFire, "return-fire", config.ReturnFire, "The Targets fire back at the Tank")
+	fs.IntVar(&config.HitPoints, "hp", config.HitPoints, "Hit Points of the Tank, with -return-fire")
+	fs.BoolVar(&config.TwoD, "2d", config.TwoD, "2D Battlefield, aim with an Angle and an Azimuth (default - 1D Impact Path)")
+	fs.Int64Var(&config.Seed, "seed", config.Seed, "Seed for the Random Values, to replay a game, any Number including 0 (default - seed from the current time)")
+	fs.StringVar(&config.Difficulty, "difficulty", config.Difficulty, "Difficulty Preset: "+getDifficultyNames())
+	fs.StringVar(&config.Strategy, "strategy", config.Strategy, "Auto Shot Strategy: "+getStrategyNames()+", or "+botPrefix+"<command> or "+botPrefix+botTCPPrefix+"<host:port> for an external Bot")
+	fs.Float64Var(&config.BotTimeout, "bot-timeout", config.BotTimeout, "Seconds that an external Bot has to reply, with -strategy "+botPrefix)
+	fs.StringVar(&config.Speed, "speed", config.Speed, "Game Speed Multiplier for Target Movement, or \"instant\" (default - 10 in Auto Shot Mode, 1 otherwise)")
+}
 
-	if printShotProfile {
-		displayShotProfile()
+func getTargetMode(shootModeAuto, targetModeAutoDefault bool) (targetModeAuto bool, targetSpeedMultiplier float64) {
+	targetModeAuto = targetModeAutoDefault
+	if shootModeAuto {
+		targetSpeedMultiplier = 10
+		targetModeAuto = true
+	} else {
+		targetSpeedMultiplier = 1
 	}
+	return
+}
 
+func getRandomValue(r *rand.Rand, min, max float64) float64 {
+	return min + float64(r.Intn(10000))*float64(max-min)/10000.0
 }
 
 func xRange(angle, v float64) (x, t float64) {
@@ -157,10 +168,6 @@ func getMilesOrKilometers(value float64, englishUnits bool) float64 {
 	return value / metersPerKilometer
 }
 
-func getRulerText(value float64) string {
-	return fmt.Sprintf("%4.1f%1s", getMilesOrKilometers(value, englishUnits), strings.Title(milesOrKilometers[englishUnits][:1]))
-}
-
 func getFeetOrMeters(value float64, englishUnits bool) float64 {
 	if englishUnits {
 		return value * feetPerMeter
@@ -168,193 +175,197 @@ func getFeetOrMeters(value float64, englishUnits bool) float64 {
 	return value
 }
 
-func getDisplayText(value float64) string {
-	return fmt.Sprintf("%3.1f %s", getFeetOrMeters(value, englishUnits), feetOrMeters[englishUnits])
-}
-
-func printHeader() {
-	fmt.Println("==================================")
-	fmt.Printf("Projectile Velocity  = %s/sec\n", getDisplayText(projectileVmps))
-	fmt.Printf("Max Projectile Range = %s\n", getDisplayText(maxRange))
-	fmt.Printf("Target Velocity      = %3.1f %s/hour\n", getMilesOrKilometers(targetVkph*metersPerKilometer, englishUnits), milesOrKilometers[englishUnits])
-	fmt.Printf("Target Velocity      = %s/sec\n", getDisplayText(targetVmps))
-	fmt.Printf("Current Target Range = %3.1f %s\n", getMilesOrKilometers(targetRange, englishUnits), milesOrKilometers[englishUnits])
-	fmt.Printf("Current Target Range = %s\n", getDisplayText(targetRange))
-	fmt.Println("----------------------------------")
-}
-
-func getImpactTimelineIndices(shotDistance, targetDistance, maxDistance float64) (shotIndex, targetIndex int) {
-	maxString := len(impactPath) - 1
+// getImpactTimelineIndices returns where a shot and its target are on an impact path width characters
+// wide. They are never drawn on top of each other, and the shot leaves room for its flight path.
+func getImpactTimelineIndices(shotDistance, targetDistance, maxDistance float64, width int) (shotIndex, targetIndex int) {
+	maxString := width - 1
 	targetIndex = int(targetDistance/maxDistance*float64(maxString)) + 1
 	shotIndex = int(shotDistance/maxDistance*float64(maxString)) + 1
 	if shotIndex == targetIndex {
-		if shotDistance < targetDistance {
+		if shotDistance < targetDistance || shotIndex == width {
 			shotIndex = targetIndex - 1
 		} else {
 			shotIndex = targetIndex + 1
 		}
 	}
-	if shotIndex < 4 || targetIndex < 5 {
-		if shotIndex < 4 && targetIndex < 5 {
+	if shotIndex < minShotIndex || targetIndex < minTargetIndex {
+		if shotIndex < minShotIndex && targetIndex < minTargetIndex {
 			if targetIndex < shotIndex {
-				shotIndex += 4 - shotIndex
+				shotIndex = minShotIndex
 				targetIndex = int(math.Max(2, float64(targetIndex)))
 			} else {
-				inc := int(math.Max(4-float64(shotIndex), 5-float64(targetIndex)))
+				inc := int(math.Max(float64(minShotIndex-shotIndex), float64(minTargetIndex-targetIndex)))
 				shotIndex += inc
 				targetIndex += inc
 			}
-		} else if shotIndex < 4 {
-			shotIndex += 4 - shotIndex
-		} else { // targetIndex < 5
+		} else if shotIndex < minShotIndex {
+			shotIndex = minShotIndex
+		} else { // targetIndex < minTargetIndex
 			targetIndex = int(math.Max(2, float64(targetIndex)))
 		}
 	}
 	return
 }
 
-func printImpactTimeline(shotDistance float64, hit bool) {
-
-	shotIndex, targetIndex := getImpactTimelineIndices(shotDistance, targetRange, maxRange)
-	curFlightPath := flightPath
-	curImpactPath := impactPath
-	if hit {
-		curFlightPath = curFlightPath[:targetIndex-2] + "\\"
-		curImpactPath = curImpactPath[:targetIndex-1] + "*" + curImpactPath[targetIndex:]
-	} else {
-		curFlightPath = curFlightPath[:shotIndex-2] + "\\"
-		curImpactPath = curImpactPath[:shotIndex-1] + "\\" + curImpactPath[shotIndex:]
-		curImpactPath = curImpactPath[:targetIndex-1] + "T" + curImpactPath[targetIndex:]
-	}
-	fmt.Println("")
-	fmt.Println(curFlightPath)
-	fmt.Println(curImpactPath)
-	fmt.Println(rulerText)
-	fmt.Println("")
-}
-
-func printImpactResults(shotRange, targetRange, shotDelta, deathRadius float64, shotCount int) bool {
-	fmt.Printf("Target Range = %s at time of impact.\n", getDisplayText(targetRange))
-	if math.Abs(shotDelta) <= deathRadius {
-		printImpactTimeline(shotRange, true)
-		fmt.Println("")
-		fmt.Printf("Direct hit (within %s) after %d shots!!\n", getDisplayText(math.Abs(shotDelta)), shotCount)
-		fmt.Println("")
-		return true
-	} else if isGameOverMan(targetRange, deathRadius) {
-		return true
-	} else {
-		if shotDelta > 0.0 {
-			fmt.Printf("<< Undershot target by %s.\n", getDisplayText(-shotDelta))
-		} else {
-			fmt.Printf(">> Overshot target by %s.\n", getDisplayText(-shotDelta))
+// readLines reads reader line by line in the background so that a prompt can be abandoned
+// without leaving the game blocked on input. The channel is closed at the end of the input.
+func readLines(reader io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			lines <- scanner.Text()
 		}
-		printImpactTimeline(shotRange, false)
-	}
-	return false
+	}()
+	return lines
 }
 
-func isGameOverMan(targetRange, deathRadius float64) bool {
-	if targetRange <= deathRadius {
-		fmt.Println("")
-		fmt.Println(gameOverMan)
-		fmt.Println("")
-		return true
-	}
-	return false
-}
-
-func takeShot(shotCount int, shotAngle, projectileVmps float64) (shotRange, shotTime, shotDelta float64) {
-	shotRange, shotTime = xRange(shotAngle, projectileVmps)
-	fmt.Printf("Taking shot #%d at %4.2f degrees. Flight time is %3.1f seconds.\n", shotCount, shotAngle, shotTime)
-	if targetModeAuto {
-		// Wait here so that the target has time to move in targetMovement() during the shot.
-		time.Sleep(time.Second * time.Duration(shotTime) / time.Duration(targetSpeedMultiplier))
-	} else {
-		// Fast forward the target to the correct location.
-		targetRange -= targetVmps * shotTime
-	}
-	fmt.Printf("Shot #%d took %3.1f seconds, and went %s (%3.1f %s).\n", shotCount, shotTime, getDisplayText(shotRange), getMilesOrKilometers(shotRange, englishUnits), milesOrKilometers[englishUnits])
-	shotDelta = targetRange - shotRange
-	return
-}
-
-func getNextShotAngle(reader io.Reader) float64 {
-	scanner := bufio.NewScanner(reader)
+// getNextShotAngle prompts until a valid shot angle up to maxAngle is entered. 0 (quit) is returned
+// at the end of the input or when ctx is cancelled.
+func getNextShotAngle(ctx context.Context, lines <-chan string, out io.Writer, maxAngle float64) float64 {
 	for {
-		fmt.Printf("Enter a shot angle from %3.1f to %3.1f degrees (0 to quit): ", minShotAngle, maxShotAngle)
-		scanner.Scan()
-		input := scanner.Text()
+		fmt.Fprintf(out, "Enter a shot angle from %3.1f to %3.1f degrees (0 to quit): ", minShotAngle, maxAngle)
+		var input string
+		select {
+		case <-ctx.Done():
+			return 0.0
+		case line, ok := <-lines:
+			if !ok {
+				return 0.0
+			}
+			input = line
+		}
 		if shotAngle, err := strconv.ParseFloat(input, 64); err == nil {
-			if shotAngle >= 0.0 && shotAngle <= maxShotAngle {
+			if shotAngle >= 0.0 && shotAngle <= maxAngle {
 				return shotAngle
 			}
 		}
-		fmt.Printf("  Invalid Value: `%s`\n", input)
+		fmt.Fprintf(out, "  Invalid Value: `%s`\n", input)
 	}
 }
 
-func predictNextShotAngle(shotRange, shotTime, shotDelta float64) float64 {
-	predictedLocation := shotRange + shotDelta - ((targetVmps * 0.95) * (shotTime * 0.95))
-	predictedAngle := xAngle(predictedLocation, projectileVmps)
-	if math.IsNaN(predictedAngle) {
-		predictedAngle = maxShotAngle
-	}
-	return math.Max(math.Min(predictedAngle, maxShotAngle), minShotAngle)
-}
-
-func battleManager() {
-	defer wg.Done()
-
-	shotAngle := 0.0
-	predictedShotAngle := maxShotAngle / 2.0
-	shotCount := 0
+// getNextShotAim is getNextShotAngle for the 2D battlefield, it also reads an azimuth from 0 up to 360
+// degrees after the angle, e.g. "22.5 310".
+func getNextShotAim(ctx context.Context, lines <-chan string, out io.Writer, maxAngle float64) (shotAngle, shotAzimuth float64) {
 	for {
-		printHeader()
-		if shootModeAuto {
-			shotAngle = predictedShotAngle
-		} else {
-			shotAngle = getNextShotAngle(os.Stdin)
-			if shotAngle == 0.0 {
-				return
+		fmt.Fprintf(out, "Enter a shot angle from %3.1f to %3.1f degrees and an azimuth from 0.0 to 360.0 degrees (0 to quit): ", minShotAngle, maxAngle)
+		var input string
+		select {
+		case <-ctx.Done():
+			return 0.0, 0.0
+		case line, ok := <-lines:
+			if !ok {
+				return 0.0, 0.0
 			}
+			input = line
 		}
-		shotCount++
-		shotRange, shotTime, shotDelta := takeShot(shotCount, shotAngle, projectileVmps)
-		if printImpactResults(shotRange, targetRange, shotDelta, deathRadius, shotCount) {
-			return
+		fields := strings.Fields(strings.Replace(input, ",", " ", -1))
+		if len(fields) == 1 {
+			if shotAngle, err := strconv.ParseFloat(fields[0], 64); err == nil && shotAngle == 0.0 {
+				return 0.0, 0.0
+			}
+		} else if len(fields) == 2 {
+			shotAngle, angleErr := strconv.ParseFloat(fields[0], 64)
+			shotAzimuth, azimuthErr := strconv.ParseFloat(fields[1], 64)
+			if angleErr == nil && azimuthErr == nil && shotAngle >= 0.0 && shotAngle <= maxAngle && shotAzimuth >= 0.0 && shotAzimuth < 360.0 {
+				return shotAngle, shotAzimuth
+			}
 		}
-		predictedShotAngle = predictNextShotAngle(shotRange, shotTime, shotDelta)
+		fmt.Fprintf(out, "  Invalid Value: `%s`\n", input)
 	}
 }
 
-func targetMovement() {
-	defer wg.Done()
-
-	movementCount := 0
-	for {
-		time.Sleep(time.Second / time.Duration(targetSpeedMultiplier))
-		targetRange -= targetVmps
-		movementCount++
-		if (movementCount % 10) == 0 {
-			note := ""
-			if targetSpeedMultiplier > 1 {
-				note = fmt.Sprintf(" (at %dx real-time)", targetSpeedMultiplier)
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "batch":
+			if err := runBatch(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			return
+		case "tournament":
+			if err := runTournament(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			return
+		case "duel":
+			if err := runDuel(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			return
+		case "serve":
+			if err := runServe(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			return
+		case "join":
+			if err := runJoin(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			return
+		case "replay":
+			if err := runReplay(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
 			}
-			fmt.Printf("Target Range = %s after %d seconds%s.\n", getDisplayText(targetRange), 10, note)
-		}
-		if isGameOverMan(targetRange, deathRadius) {
 			return
 		}
 	}
-}
 
-func main() {
-	initialize()
-	wg.Add(1)
-	if targetModeAuto {
-		go targetMovement()
+	config, output, err := parseFlags()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	var out, events io.Writer = os.Stdout, nil
+	var input io.Reader = os.Stdin
+	var screen *tui
+	if output.JSON {
+		out, events = io.Discard, os.Stdout
+	}
+	if output.TUI {
+		screen = newTUI(os.Stdout)
+		out, events, input = screen, screen.events(), screen.input(os.Stdin)
+	}
+	if output.Events != "" {
+		f, err := os.Create(output.Events)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		defer f.Close()
+		if screen != nil {
+			events = io.MultiWriter(events, f)
+		} else {
+			events = f
+		}
+	}
+	game, err := NewGame(config, input, out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if events != nil {
+		game.streamEvents(events)
+	}
+	sizeTimelineForStdout(game, output.Width)
+	game.color = output.colorEnabled()
+	if screen != nil {
+		screen.attach(game)
+		screen.play(context.Background())
+	} else {
+		game.Run(context.Background())
+	}
+	if output.Record != "" {
+		if err := saveRecording(output.Record, game.recording()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 	}
-	go battleManager()
-	wg.Wait()
 }
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"io"
+	"math/rand"
+	"os"
 	"strings"
 	"testing"
 )
@@ -59,7 +62,7 @@ func Test_getRandomValue(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getRandomValue(tt.args.min, tt.args.max); got <= tt.args.min || got >= tt.args.max {
+			if got := getRandomValue(rand.New(rand.NewSource(1)), tt.args.min, tt.args.max); got <= tt.args.min || got >= tt.args.max {
 				t.Errorf("getRandomValue() = %v, want >= %v && want <= %v", got, tt.args.min, tt.args.max)
 			}
 		})
@@ -135,7 +138,7 @@ func Test_getGameModes(t *testing.T) {
 		name               string
 		args               args
 		wantTargetModeAuto bool
-		wantGameSpeed      int
+		wantGameSpeed      float64
 	}{
 		{
 			name:               "Default",
@@ -180,6 +183,7 @@ func Test_getImpactTimelineIndices(t *testing.T) {
 		shotDistance   float64
 		targetDistance float64
 		maxDistance    float64
+		width          int
 	}
 	tests := []struct {
 		name            string
@@ -189,44 +193,56 @@ func Test_getImpactTimelineIndices(t *testing.T) {
 	}{
 		{
 			name:            "Test undershot and ensure that 'T' is in correct location",
-			args:            args{1.0, 20.0, 100.0},
+			args:            args{1.0, 20.0, 100.0, defaultTimelineWidth},
 			wantShotIndex:   4,
 			wantTargetIndex: 10,
 		},
 		{
 			name:            "Test short undershot and ensure that 'T' is in correct location",
-			args:            args{1.0, 2.0, 1000.0},
+			args:            args{1.0, 2.0, 1000.0, defaultTimelineWidth},
 			wantShotIndex:   4,
 			wantTargetIndex: 5,
 		},
 		{
 			name:            "Test short overshot and ensure that 'T' is in correct location",
-			args:            args{2.0, 1.0, 1000.0},
+			args:            args{2.0, 1.0, 1000.0, defaultTimelineWidth},
 			wantShotIndex:   4,
 			wantTargetIndex: 2,
 		},
 		{
 			name:            "Test overshot of short target and ensure that 'T' is in correct location",
-			args:            args{30.0, 1.0, 100.0},
+			args:            args{30.0, 1.0, 100.0, defaultTimelineWidth},
 			wantShotIndex:   15,
 			wantTargetIndex: 2,
 		},
 		{
 			name:            "Test overshot at same location",
-			args:            args{10.0, 10.1, 100.0},
+			args:            args{10.0, 10.1, 100.0, defaultTimelineWidth},
 			wantShotIndex:   4,
 			wantTargetIndex: 5,
 		},
 		{
 			name:            "Test undershot at same location",
-			args:            args{10.1, 10.0, 100.0},
+			args:            args{10.1, 10.0, 100.0, defaultTimelineWidth},
 			wantShotIndex:   6,
 			wantTargetIndex: 5,
 		},
+		{
+			name:            "Test overshot at the end of the timeline",
+			args:            args{100.0, 100.0, 100.0, defaultTimelineWidth},
+			wantShotIndex:   49,
+			wantTargetIndex: 50,
+		},
+		{
+			name:            "Test undershot on a wide timeline",
+			args:            args{20.0, 30.0, 100.0, 201},
+			wantShotIndex:   41,
+			wantTargetIndex: 61,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotShotIndex, gotTargetIndex := getImpactTimelineIndices(tt.args.shotDistance, tt.args.targetDistance, tt.args.maxDistance)
+			gotShotIndex, gotTargetIndex := getImpactTimelineIndices(tt.args.shotDistance, tt.args.targetDistance, tt.args.maxDistance, tt.args.width)
 			if gotShotIndex != tt.wantShotIndex {
 				t.Errorf("getImpactTimelineIndices() gotShotIndex = %v, want %v", gotShotIndex, tt.wantShotIndex)
 			}
@@ -260,7 +276,8 @@ func Test_isGameOverMan(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := isGameOverMan(tt.args.targetRange, tt.args.deathRadius); got != tt.want {
+			g := &Game{config: Config{DeathRadius: tt.args.deathRadius}, out: os.Stdout}
+			if got := g.isGameOverMan(tt.args.targetRange); got != tt.want {
 				t.Errorf("isGameOverMan() = %v, want %v", got, tt.want)
 			}
 		})
@@ -303,7 +320,10 @@ func Test_printImpactResults(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := printImpactResults(tt.args.shotRange, tt.args.targetRange, tt.args.shotDelta, tt.args.deathRadius, tt.args.shotCount); got != tt.want {
+			g := &Game{config: Config{DeathRadius: tt.args.deathRadius}, out: os.Stdout, targets: []target{{distance: tt.args.targetRange}}, maxRange: 1000.0}
+			g.setTimelineWidth(defaultTimelineWidth)
+			shot := shotResult{Range: tt.args.shotRange, Delta: tt.args.shotDelta, Target: 1}
+			if got := g.printImpactResults(shot, tt.args.shotCount); got != tt.want {
 				t.Errorf("printImpactResults() = %v, want %v", got, tt.want)
 			}
 		})
@@ -335,9 +355,9 @@ func Test_takeShot(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			targetRange = tt.args.targetRange
-			targetVmps = tt.args.targetVmps
-			gotShotRange, gotShotTime, gotShotDelta := takeShot(tt.args.shotCount, tt.args.shotAngle, tt.args.projectileVmps)
+			g := &Game{out: os.Stdout, clock: newInstantClock(1), targets: []target{{vmps: tt.args.targetVmps, distance: tt.args.targetRange}}, projectileVmps: tt.args.projectileVmps}
+			shot := g.takeShot(context.Background(), tt.args.shotCount, tt.args.shotAngle, 0.0)
+			gotShotRange, gotShotTime, gotShotDelta := shot.Range, shot.FlightTime, shot.Delta
 			if gotShotRange != tt.wantShotRange {
 				t.Errorf("takeShot() gotShotRange = %v, want %v", gotShotRange, tt.wantShotRange)
 			}
@@ -358,6 +378,7 @@ func Test_predictNextShotAngle(t *testing.T) {
 		shotRange      float64
 		shotTime       float64
 		shotDelta      float64
+		drag           bool
 	}
 	tests := []struct {
 		name string
@@ -366,20 +387,32 @@ func Test_predictNextShotAngle(t *testing.T) {
 	}{
 		{
 			name: "Test 1",
-			args: args{0.0, 600.0, 2689.835682980686, 23.429597899903456, 7310.164317019314},
+			args: args{0.0, 600.0, 2689.835682980686, 23.429597899903456, 7310.164317019314, false},
 			want: 7.903772202260505,
 		},
 		{
 			name: "Out of Range",
-			args: args{0.0, 300.0, 2689.835682980686, 23.429597899903456, 7310.164317019314},
+			args: args{0.0, 300.0, 2689.835682980686, 23.429597899903456, 7310.164317019314, false},
 			want: maxShotAngle,
 		},
+		{
+			name: "Target predicted behind the Tank",
+			args: args{20.0, 400.0, 100.0, 20.0, 0.0, false},
+			want: minShotAngle,
+		},
+		{
+			name: "Target predicted behind the Tank with Drag",
+			args: args{20.0, 400.0, 100.0, 20.0, 0.0, true},
+			want: minShotAngle,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			targetVmps = tt.args.targetVmps
-			projectileVmps = tt.args.projectileVmps
-			if got := predictNextShotAngle(tt.args.shotRange, tt.args.shotTime, tt.args.shotDelta); got != tt.want {
+			obs := observation{TargetVmps: tt.args.targetVmps, ProjectileVmps: tt.args.projectileVmps}
+			if tt.args.drag {
+				obs.ballistics.drag, _ = newDragModel(defaultDragCoefficient, defaultProjectileMass, defaultCaliber)
+			}
+			if got := predictNextShotAngle(obs, tt.args.shotRange, tt.args.shotTime, tt.args.shotDelta); got != tt.want {
 				t.Errorf("predictNextShotAngle() = %v, want %v", got, tt.want)
 			}
 		})
@@ -388,7 +421,8 @@ func Test_predictNextShotAngle(t *testing.T) {
 
 func Test_getNextShotAngle(t *testing.T) {
 	type args struct {
-		reader io.Reader
+		reader   io.Reader
+		maxAngle float64
 	}
 	tests := []struct {
 		name string
@@ -397,30 +431,82 @@ func Test_getNextShotAngle(t *testing.T) {
 	}{
 		{
 			name: "Return 0",
-			args: args{strings.NewReader("0\n")},
+			args: args{strings.NewReader("0\n"), maxShotAngle},
 			want: 0.0,
 		},
 		{
 			name: "Return 1.359",
-			args: args{strings.NewReader("1.359\n")},
+			args: args{strings.NewReader("1.359\n"), maxShotAngle},
 			want: 1.359,
 		},
 		{
 			name: "Return 10.0 after errors",
-			args: args{strings.NewReader("10.a\n10\n")},
+			args: args{strings.NewReader("10.a\n10\n"), maxShotAngle},
 			want: 10.0,
 		},
 		{
 			name: "Return 45.0 after 46 out of range",
-			args: args{strings.NewReader("46\n45\n")},
+			args: args{strings.NewReader("46\n45\n"), maxShotAngle},
 			want: 45.0,
 		},
+		{
+			name: "Return 60.0 with high-angle fire",
+			args: args{strings.NewReader("60\n"), maxHighShotAngle},
+			want: 60.0,
+		},
+		{
+			name: "Return 0 at end of input",
+			args: args{strings.NewReader("10.a\n"), maxShotAngle},
+			want: 0.0,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getNextShotAngle(tt.args.reader); got != tt.want {
+			if got := getNextShotAngle(context.Background(), readLines(tt.args.reader), os.Stdout, tt.args.maxAngle); got != tt.want {
 				t.Errorf("getNextShotAngle() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_getNextShotAim(t *testing.T) {
+	tests := []struct {
+		name        string
+		reader      io.Reader
+		wantAngle   float64
+		wantAzimuth float64
+	}{
+		{
+			name:        "Return 0",
+			reader:      strings.NewReader("0\n"),
+			wantAngle:   0.0,
+			wantAzimuth: 0.0,
+		},
+		{
+			name:        "Return 22.5 at 310",
+			reader:      strings.NewReader("22.5 310\n"),
+			wantAngle:   22.5,
+			wantAzimuth: 310.0,
+		},
+		{
+			name:        "Return 10.0 at 90 after errors",
+			reader:      strings.NewReader("10\n10 360\n10 a\n10, 90\n"),
+			wantAngle:   10.0,
+			wantAzimuth: 90.0,
+		},
+		{
+			name:        "Return 0 at end of input",
+			reader:      strings.NewReader("10\n"),
+			wantAngle:   0.0,
+			wantAzimuth: 0.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAngle, gotAzimuth := getNextShotAim(context.Background(), readLines(tt.reader), io.Discard, maxShotAngle)
+			if gotAngle != tt.wantAngle || gotAzimuth != tt.wantAzimuth {
+				t.Errorf("getNextShotAim() = %v, %v, want %v, %v", gotAngle, gotAzimuth, tt.wantAngle, tt.wantAzimuth)
+			}
+		})
+	}
+}
@@ -0,0 +1,575 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMatchAddr    = "localhost:7777"
+	defaultMatchPlayers = 2
+	matchWriteTimeout   = 5 * time.Second  // a client that doesn't read for this long misses messages
+	matchJoinTimeout    = 30 * time.Second // a client that doesn't join for this long is hung up on
+)
+
+// A match is a game that several players play over TCP, one JSON message per line. The server owns
+// the game and its clock, and relays the JSON event stream of the game to every client together with
+// the match messages below. The players take turns to shoot at the same targets, and the client of a
+// player draws the header and the timeline from the events, with a copy of the game.
+
+// matchMessage is a message from a client to the server.
+type matchMessage struct {
+	Type    string  `json:"type"` // join, shot or quit
+	Name    string  `json:"name,omitempty"`
+	Angle   float64 `json:"angle,omitempty"`
+	Azimuth float64 `json:"azimuth,omitempty"` // with -2d
+}
+
+// matchPlayerState is a player in the match messages from the server.
+type matchPlayerState struct {
+	Player int    `json:"player"`
+	Name   string `json:"name"`
+	Shots  int    `json:"shots"`
+	Hits   int    `json:"hits"`
+}
+
+type welcomeMessage struct {
+	Type    string `json:"type"`
+	Player  int    `json:"player"` // the player of the client
+	Players int    `json:"players"`
+}
+
+type matchStartMessage struct {
+	Type    string             `json:"type"`
+	Players []matchPlayerState `json:"players"`
+}
+
+type turnMessage struct {
+	Type   string `json:"type"`
+	Player int    `json:"player"`
+	Shot   int    `json:"shot"`
+}
+
+type errorMessage struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type matchOverMessage struct {
+	Type    string             `json:"type"`
+	Outcome outcome            `json:"outcome"`
+	Winner  int                `json:"winner"` // 0 = nobody won
+	Players []matchPlayerState `json:"players"`
+}
+
+// matchPlayer is a client of the server.
+type matchPlayer struct {
+	matchPlayerState
+	conn net.Conn
+	mu   sync.Mutex // guards writes to conn
+}
+
+func (p *matchPlayer) send(m interface{}) {
+	line, _ := json.Marshal(m)
+	p.write(append(line, '\n'))
+}
+
+func (p *matchPlayer) write(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conn.SetWriteDeadline(time.Now().Add(matchWriteTimeout))
+	p.conn.Write(line)
+}
+
+// playerMessage is a message that the server received from a player.
+type playerMessage struct {
+	player *matchPlayer
+	matchMessage
+}
+
+// match is the server side of a match.
+type match struct {
+	g        *Game
+	players  []*matchPlayer
+	messages chan playerMessage // from every player, a quit when a client disconnects
+	awaiting chan int           // the shot that the game waits for
+	mu       sync.Mutex         // guards the players while they join, and their scores
+}
+
+// runServe is the serve command: it waits for the players on the address and then hosts the match.
+func runServe(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	configFlags := newConfigFlags(fs)
+	addr := fs.String("addr", defaultMatchAddr, "Address to listen on for the Players")
+	players := fs.Int("players", defaultMatchPlayers, "Number of Players in the Match")
+	fs.Parse(args)
+	config, err := configFlags.resolve()
+	if err != nil {
+		return err
+	}
+	m, err := newMatch(config, *players)
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	fmt.Fprintf(out, "Waiting for %d players on %s\n", *players, ln.Addr())
+	return m.serve(context.Background(), ln, out)
+}
+
+// newMatch creates the game of a match. The players shoot by hand, and the targets move in real-time
+// on the clock of the server.
+func newMatch(config Config, players int) (*match, error) {
+	if players < 1 || players > maxTargets {
+		return nil, fmt.Errorf("invalid number of players %d: want 1 to %d", players, maxTargets)
+	}
+	config.ShootModeAuto, config.TargetModeAuto = false, true
+	g, err := NewGame(config, nil, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	m := &match{
+		g:        g,
+		players:  make([]*matchPlayer, 0, players),
+		messages: make(chan playerMessage),
+		awaiting: make(chan int, 1),
+	}
+	g.streamEvents(m)
+	return m, nil
+}
+
+// serve waits for the players to join on ln, and then plays the match.
+func (m *match) serve(ctx context.Context, ln net.Listener, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	joins, full := make(chan matchJoin), make(chan struct{})
+	go m.accept(ctx, ln, joins, full)
+	for len(m.players) < cap(m.players) {
+		var j matchJoin
+		select {
+		case j = <-joins:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if j.err != nil {
+			return j.err
+		}
+		p := &matchPlayer{conn: j.conn}
+		p.Player = len(m.players) + 1
+		p.Name = fmt.Sprintf("Player %d", p.Player)
+		if j.name != "" {
+			p.Name = j.name
+		}
+		defer j.conn.Close()
+		m.mu.Lock()
+		m.players = append(m.players, p)
+		m.mu.Unlock()
+		p.send(welcomeMessage{Type: "welcome", Player: p.Player, Players: cap(m.players)})
+		fmt.Fprintf(out, "%s joined as Player %d\n", p.Name, p.Player)
+		go m.receive(ctx, p, j.reader)
+	}
+	close(full)
+
+	fmt.Fprintln(out, "The match starts")
+	m.broadcast(matchStartMessage{Type: "match_start", Players: m.scores()})
+	lines := make(chan string)
+	m.g.lines = lines
+	go m.takeTurns(ctx, lines)
+	m.g.Run(ctx)
+
+	result := m.g.result()
+	over := matchOverMessage{Type: "match_over", Outcome: result.Outcome, Winner: m.winner(result.Outcome), Players: m.scores()}
+	m.broadcast(over)
+	fmt.Fprintf(out, "The match is over: %s, winner %d\n", over.Outcome, over.Winner)
+	return nil
+}
+
+// matchJoin is a client that sent its join line, or the error that stopped the listener.
+type matchJoin struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	name   string
+	err    error
+}
+
+// accept passes the clients that join on ln to joins, until the match is full. Each client sends its
+// join line on its own, so that a silent client doesn't keep the others from joining, and the server
+// hangs up on a client that doesn't join in time. A client that comes too late gets an error.
+func (m *match) accept(ctx context.Context, ln net.Listener, joins chan<- matchJoin, full <-chan struct{}) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			select {
+			case joins <- matchJoin{err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+		select {
+		case <-full:
+			go rejectMatchPlayer(conn)
+			continue
+		default:
+		}
+		go func() {
+			reader := bufio.NewReader(conn)
+			var join matchMessage
+			conn.SetReadDeadline(time.Now().Add(matchJoinTimeout))
+			if line, err := reader.ReadBytes('\n'); err != nil || json.Unmarshal(line, &join) != nil || join.Type != "join" {
+				conn.Close()
+				return
+			}
+			conn.SetReadDeadline(time.Time{})
+			select {
+			case joins <- matchJoin{conn: conn, reader: reader, name: join.Name}:
+			case <-full:
+				rejectMatchPlayer(conn)
+			case <-ctx.Done():
+				conn.Close()
+			}
+		}()
+	}
+}
+
+// rejectMatchPlayer tells a client that came too late that the match is full, and hangs up.
+func rejectMatchPlayer(conn net.Conn) {
+	defer conn.Close()
+	p := &matchPlayer{conn: conn}
+	p.send(errorMessage{Type: "error", Message: "the match is full"})
+	// Hang up once the client has read the error, a join line left unread would reset the connection.
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.CloseWrite()
+	}
+	conn.SetReadDeadline(time.Now().Add(matchWriteTimeout))
+	io.Copy(io.Discard, conn)
+}
+
+// receive passes the messages of player p on to the match, until the client disconnects.
+func (m *match) receive(ctx context.Context, p *matchPlayer, reader *bufio.Reader) {
+	for {
+		var msg matchMessage
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			msg.Type = "quit"
+		} else if json.Unmarshal(line, &msg) != nil {
+			p.send(errorMessage{Type: "error", Message: "invalid message"})
+			continue
+		}
+		select {
+		case m.messages <- playerMessage{p, msg}:
+		case <-ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// takeTurns hands the prompts of the game to the players in turn, and passes their shots on to the
+// game on lines. A player that quits ends the match.
+func (m *match) takeTurns(ctx context.Context, lines chan<- string) {
+	for {
+		var shot int
+		select {
+		case shot = <-m.awaiting:
+		case <-ctx.Done():
+			return
+		}
+		p := m.players[(shot-1)%len(m.players)]
+		m.broadcast(turnMessage{Type: "turn", Player: p.Player, Shot: shot})
+		line := ""
+		for line == "" {
+			var msg playerMessage
+			select {
+			case msg = <-m.messages:
+			case <-ctx.Done():
+				return
+			}
+			switch {
+			case msg.Type == "quit":
+				line = "0"
+			case msg.Type != "shot":
+				msg.player.send(errorMessage{Type: "error", Message: fmt.Sprintf("unknown message type %q", msg.Type)})
+			case msg.player != p:
+				msg.player.send(errorMessage{Type: "error", Message: fmt.Sprintf("not your turn, waiting for %s", p.Name)})
+			case msg.Angle <= 0.0 || msg.Angle > m.g.maxAngle() || msg.Azimuth < 0.0 || msg.Azimuth >= 360.0:
+				p.send(errorMessage{Type: "error", Message: fmt.Sprintf("invalid shot angle %g or azimuth %g", msg.Angle, msg.Azimuth)})
+			default:
+				line = strconv.FormatFloat(msg.Angle, 'g', -1, 64)
+				if m.g.config.TwoD {
+					line += " " + strconv.FormatFloat(msg.Azimuth, 'g', -1, 64)
+				}
+				m.mu.Lock()
+				p.Shots++
+				m.mu.Unlock()
+			}
+		}
+		select {
+		case lines <- line:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Write relays a line of the event stream of the game to every player, and keeps the score.
+func (m *match) Write(line []byte) (int, error) {
+	var e struct {
+		Type string `json:"type"`
+		Shot int    `json:"shot"`
+	}
+	json.Unmarshal(line, &e)
+	if e.Type == "hit" {
+		m.mu.Lock()
+		m.players[(e.Shot-1)%len(m.players)].Hits++
+		m.mu.Unlock()
+	}
+	for _, p := range m.players {
+		p.write(line)
+	}
+	if e.Type == "awaiting_shot" {
+		// The game waits for one shot at a time, so there is always room.
+		select {
+		case m.awaiting <- e.Shot:
+		default:
+		}
+	}
+	return len(line), nil
+}
+
+func (m *match) broadcast(msg interface{}) {
+	for _, p := range m.players {
+		p.send(msg)
+	}
+}
+
+func (m *match) scores() []matchPlayerState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	scores := make([]matchPlayerState, len(m.players))
+	for i, p := range m.players {
+		scores[i] = p.matchPlayerState
+	}
+	return scores
+}
+
+// winner returns the player that destroyed the most targets, the last of them on a tie. Nobody wins
+// when the targets win or a player quits.
+func (m *match) winner(o outcome) int {
+	if o != outcomeHit {
+		return 0
+	}
+	// The last shot destroyed the last target. The game is asked before m.mu is taken, because the
+	// game holds its own lock while it writes the hits to the match.
+	shots := m.g.result().Shots
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	winner := m.players[(shots-1)%len(m.players)]
+	for _, p := range m.players {
+		if p.Hits > winner.Hits {
+			winner = p
+		}
+	}
+	return winner.Player
+}
+
+// matchClient is the client side of a match, it draws the game from the messages of the server.
+type matchClient struct {
+	out     io.Writer
+	input   <-chan string
+	conn    net.Conn
+	player  int
+	names   map[int]string
+	mirror  *Game // draws the game, with the state from the events
+	clock   *manualClock
+	turn    int // the player that shoots next
+	cancel  context.CancelFunc
+	outcome outcome
+	winner  int
+	mu      sync.Mutex // guards writes to conn
+}
+
+// runJoin is the join command: it connects to the server at the address and plays the match.
+func runJoin(args []string, input io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("join", flag.ExitOnError)
+	name := fs.String("name", "", "Player Name (default - Player <n>)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: tank join [-name <name>] <host:port>")
+	}
+	conn, err := net.Dial("tcp", fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	c := &matchClient{out: out, input: readLines(input), conn: conn, names: map[int]string{}}
+	return c.play(context.Background(), *name)
+}
+
+func (c *matchClient) send(msg matchMessage) {
+	line, _ := json.Marshal(msg)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.Write(append(line, '\n'))
+}
+
+// play joins the match as name and draws it until it is over.
+func (c *matchClient) play(ctx context.Context, name string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.send(matchMessage{Type: "join", Name: name})
+	for line := range readLines(c.conn) {
+		var header eventHeader
+		if err := json.Unmarshal([]byte(line), &header); err != nil {
+			return fmt.Errorf("invalid message from the server: %v", err)
+		}
+		over, err := c.handle(ctx, header, []byte(line))
+		if err != nil {
+			return err
+		}
+		if over {
+			return nil
+		}
+	}
+	return fmt.Errorf("lost the connection to the server")
+}
+
+// handle draws one message of the server, and reports whether the match is over.
+func (c *matchClient) handle(ctx context.Context, header eventHeader, line []byte) (bool, error) {
+	if c.clock != nil && seconds(header.Time) > c.clock.Now() {
+		c.clock.Advance(seconds(header.Time) - c.clock.Now())
+	}
+	switch header.Type {
+	case "welcome":
+		var msg welcomeMessage
+		json.Unmarshal(line, &msg)
+		c.player = msg.Player
+		fmt.Fprintf(c.out, "Joined the match as Player %d of %d, waiting for the other players...\n", msg.Player, msg.Players)
+	case "match_start":
+		var msg matchStartMessage
+		json.Unmarshal(line, &msg)
+		for _, p := range msg.Players {
+			c.names[p.Player] = p.Name
+			fmt.Fprintf(c.out, "Player %d = %s\n", p.Player, p.Name)
+		}
+	case "game_start":
+		var e gameStartEvent
+		json.Unmarshal(line, &e)
+		// The same configuration and seed draw the same game as on the server.
+		mirror, err := NewGame(e.Config, nil, c.out)
+		if err != nil {
+			return false, err
+		}
+		c.clock = newManualClock()
+		mirror.clock = c.clock
+		mirror.printConfiguration()
+		c.mirror = mirror
+	case "target_moved":
+		var e targetMovedEvent
+		json.Unmarshal(line, &e)
+		c.mirror.targets[e.Target-1].distance = e.TargetRange
+	case "awaiting_shot":
+		var e awaitingShotEvent
+		json.Unmarshal(line, &e)
+		c.mirror.setWind(e.Wind * secondsPerHour / metersPerKilometer)
+	case "turn":
+		var msg turnMessage
+		json.Unmarshal(line, &msg)
+		c.turn = msg.Player
+		c.mirror.printHeader()
+		if msg.Player != c.player {
+			fmt.Fprintf(c.out, "Waiting for %s to take shot #%d...\n", c.names[msg.Player], msg.Shot)
+			break
+		}
+		var turnCtx context.Context
+		turnCtx, c.cancel = context.WithCancel(ctx)
+		go c.prompt(turnCtx)
+	case "error":
+		var msg errorMessage
+		json.Unmarshal(line, &msg)
+		fmt.Fprintf(c.out, "  Server: %s\n", msg.Message)
+	case "shot_fired":
+		var e shotFiredEvent
+		json.Unmarshal(line, &e)
+		azimuth := ""
+		if c.mirror.config.TwoD {
+			azimuth = fmt.Sprintf(" and %4.2f degrees azimuth", e.Azimuth)
+		}
+		fmt.Fprintf(c.out, "%s takes shot #%d at %4.2f degrees%s. Flight time is %3.1f seconds.\n", c.names[c.turn], e.Shot, e.Angle, azimuth, e.FlightTime)
+	case "shot_impact":
+		var e shotImpactEvent
+		json.Unmarshal(line, &e)
+		g := c.mirror
+		fmt.Fprintf(c.out, "Shot #%d took %3.1f seconds, and went %s (%3.1f %s).\n", e.Shot, e.FlightTime, g.getDisplayText(e.Range), getMilesOrKilometers(e.Range, g.config.EnglishUnits), milesOrKilometers[g.config.EnglishUnits])
+		shot := shotResult{Angle: e.Angle, Azimuth: e.Azimuth, Range: e.Range, FlightTime: e.FlightTime, Delta: e.Delta, Lateral: e.Lateral, Target: e.Target, TargetRange: e.TargetRange}
+		g.printImpactResults(shot, e.Shot)
+	case "enemy_impact":
+		var e enemyImpactEvent
+		json.Unmarshal(line, &e)
+		c.mirror.landShell(enemyLanding{enemyShell{landsAt: e.LandedAt, miss: e.Miss}, e.Target - 1})
+	case "crushed":
+		var e crushedEvent
+		json.Unmarshal(line, &e)
+		if c.mirror.outcome == outcomeNone {
+			c.mirror.isGameOverMan(e.TargetRange)
+		}
+	case "match_over":
+		if c.cancel != nil {
+			c.cancel()
+		}
+		var msg matchOverMessage
+		json.Unmarshal(line, &msg)
+		c.outcome, c.winner = msg.Outcome, msg.Winner
+		c.printSummary(msg)
+		return true, nil
+	}
+	return false, nil
+}
+
+// prompt asks the player for the next shot and sends it to the server.
+func (c *matchClient) prompt(ctx context.Context) {
+	var shotAngle, shotAzimuth float64
+	if c.mirror.config.TwoD {
+		shotAngle, shotAzimuth = getNextShotAim(ctx, c.input, c.out, c.mirror.maxAngle())
+	} else {
+		shotAngle = getNextShotAngle(ctx, c.input, c.out, c.mirror.maxAngle())
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	if shotAngle == 0.0 {
+		c.send(matchMessage{Type: "quit"})
+		return
+	}
+	c.send(matchMessage{Type: "shot", Angle: shotAngle, Azimuth: shotAzimuth})
+}
+
+func (c *matchClient) printSummary(msg matchOverMessage) {
+	fmt.Fprintln(c.out, "==================================")
+	switch {
+	case msg.Winner == c.player:
+		fmt.Fprintln(c.out, "You win the match!")
+	case msg.Winner > 0:
+		fmt.Fprintf(c.out, "%s wins the match!\n", c.names[msg.Winner])
+	case msg.Outcome == outcomeQuit:
+		fmt.Fprintln(c.out, "The match was abandoned.")
+	default:
+		fmt.Fprintln(c.out, "The targets win the match.")
+	}
+	for _, p := range msg.Players {
+		fmt.Fprintf(c.out, "%-21s= %d shots, %d hits\n", p.Name, p.Shots, p.Hits)
+	}
+	fmt.Fprintln(c.out, "==================================")
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMatch_serve(t *testing.T) {
+	hit := fmt.Sprintf("%v\n", xAngle(5000.0, 400.0))
+	tests := []struct {
+		name        string
+		inputs      []string
+		wantOutcome outcome
+		wantWinner  int
+		wantShots   []int
+	}{
+		{
+			name:        "Player 2 hits after a miss",
+			inputs:      []string{"10\n", hit},
+			wantOutcome: outcomeHit,
+			wantWinner:  2,
+			wantShots:   []int{1, 1},
+		},
+		{
+			name:        "Player 1 hits alone",
+			inputs:      []string{hit},
+			wantOutcome: outcomeHit,
+			wantWinner:  1,
+			wantShots:   []int{1},
+		},
+		{
+			name:        "Player 2 leaves",
+			inputs:      []string{"10\n20\n", ""},
+			wantOutcome: outcomeQuit,
+			wantWinner:  0,
+			wantShots:   []int{1, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := withDefaults(Config{DeathRadius: impactRadius, Speed: "instant", Seed: 1})
+			config.MinProjectileVmps, config.MaxProjectileVmps = 400.0, 400.0
+			config.MinTargetVkph, config.MaxTargetVkph = 0.0, 0.0
+			config.MinStartMeters, config.MaxStartMeters = 5000.0, 5000.0
+			m, err := newMatch(config, len(tt.inputs))
+			if err != nil {
+				t.Fatalf("newMatch() error = %v", err)
+			}
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Listen() error = %v", err)
+			}
+			defer ln.Close()
+			served := make(chan error, 1)
+			go func() {
+				served <- m.serve(context.Background(), ln, io.Discard)
+			}()
+
+			// The players join one after the other, so that they get their numbers in order.
+			var wg sync.WaitGroup
+			clients := make([]*matchClient, len(tt.inputs))
+			for i, input := range tt.inputs {
+				conn, err := net.Dial("tcp", ln.Addr().String())
+				if err != nil {
+					t.Fatalf("Dial() error = %v", err)
+				}
+				defer conn.Close()
+				clients[i] = &matchClient{out: io.Discard, input: readLines(strings.NewReader(input)), conn: conn, names: map[int]string{}}
+				wg.Add(1)
+				go func(c *matchClient, name string) {
+					defer wg.Done()
+					if err := c.play(context.Background(), name); err != nil {
+						t.Errorf("play() error = %v", err)
+					}
+				}(clients[i], fmt.Sprintf("player%d", i+1))
+				for len(m.scores()) <= i {
+					time.Sleep(time.Millisecond)
+				}
+			}
+			wg.Wait()
+			select {
+			case err := <-served:
+				if err != nil {
+					t.Errorf("serve() error = %v", err)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("serve() did not return")
+			}
+
+			for i, c := range clients {
+				if c.player != i+1 || c.outcome != tt.wantOutcome || c.winner != tt.wantWinner {
+					t.Errorf("client %d: player = %d, outcome = %q, winner = %d, want %d, %q, %d", i, c.player, c.outcome, c.winner, i+1, tt.wantOutcome, tt.wantWinner)
+				}
+			}
+			for i, score := range m.scores() {
+				if score.Shots != tt.wantShots[i] {
+					t.Errorf("Player %d shots = %d, want %d", i+1, score.Shots, tt.wantShots[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMatch_serve_silentClient(t *testing.T) {
+	config := withDefaults(Config{DeathRadius: impactRadius, Speed: "instant", Seed: 1})
+	config.MinProjectileVmps, config.MaxProjectileVmps = 400.0, 400.0
+	config.MinTargetVkph, config.MaxTargetVkph = 0.0, 0.0
+	config.MinStartMeters, config.MaxStartMeters = 5000.0, 5000.0
+	m, err := newMatch(config, 1)
+	if err != nil {
+		t.Fatalf("newMatch() error = %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer ln.Close()
+	served := make(chan error, 1)
+	go func() {
+		served <- m.serve(context.Background(), ln, io.Discard)
+	}()
+
+	// The silent client connects first and never sends its join line.
+	silent, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer silent.Close()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	input := fmt.Sprintf("%v\n", xAngle(5000.0, 400.0))
+	c := &matchClient{out: io.Discard, input: readLines(strings.NewReader(input)), conn: conn, names: map[int]string{}}
+	played := make(chan error, 1)
+	go func() {
+		played <- c.play(context.Background(), "player1")
+	}()
+	select {
+	case err := <-played:
+		if err != nil {
+			t.Errorf("play() error = %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("play() did not return, the silent client keeps the player from joining")
+	}
+	if err := <-served; err != nil {
+		t.Errorf("serve() error = %v", err)
+	}
+	if c.player != 1 || c.outcome != outcomeHit || c.winner != 1 {
+		t.Errorf("player = %d, outcome = %q, winner = %d, want 1, %q, 1", c.player, c.outcome, c.winner, outcomeHit)
+	}
+
+	// A client that comes after the match is full is told so, and hung up on.
+	late, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer late.Close()
+	late.SetReadDeadline(time.Now().Add(10 * time.Second))
+	fmt.Fprintln(late, `{"type":"join","name":"late"}`)
+	reply, err := io.ReadAll(late)
+	if err != nil || !strings.Contains(string(reply), "the match is full") {
+		t.Errorf("late client got %q, error = %v, want the match is full and a hang up", reply, err)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recordingVersion changes whenever a recording could no longer be replayed the same way.
+const recordingVersion = 1
+
+const replayHelp = "Replay Controls: Enter = pause/resume, s = step to the next shot, + = faster, - = slower, q = quit"
+
+// recording is everything needed to play a game again: the configuration with its seed decides the
+// starting values, and the shots decide the rest.
+type recording struct {
+	Version int            `json:"version"`
+	Config  Config         `json:"config"`
+	Shots   []recordedShot `json:"shots"`
+	Outcome outcome        `json:"outcome"`
+	EndedAt float64        `json:"ended_at"`       // simulated seconds since the start of the game
+	Duel    bool           `json:"duel,omitempty"` // a hot-seat duel, the players took turns at the shots
+}
+
+type recordedShot struct {
+	Angle   float64 `json:"angle"`
+	Azimuth float64 `json:"azimuth,omitempty"` // degrees clockwise from north, 2D only
+	FiredAt float64 `json:"fired_at"`          // simulated seconds since the start of the game
+}
+
+// recording returns the recording of the game, once Run has returned.
+func (g *Game) recording() recording {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return recording{
+		Version: recordingVersion,
+		Config:  g.config,
+		Shots:   g.recordedShots,
+		Outcome: g.outcome,
+		EndedAt: g.clock.Now().Seconds(),
+		Duel:    g.duel != nil,
+	}
+}
+
+func saveRecording(path string, rec recording) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func loadRecording(path string) (rec recording, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rec, err
+	}
+	if err = json.Unmarshal(data, &rec); err != nil {
+		return rec, fmt.Errorf("invalid recording %s: %v", path, err)
+	}
+	if rec.Version != recordingVersion {
+		return rec, fmt.Errorf("invalid recording %s: version %d, want %d", path, rec.Version, recordingVersion)
+	}
+	return rec, nil
+}
+
+// runReplay is the replay command: it plays a recorded game again, with controls on in.
+func runReplay(args []string, in io.Reader, out io.Writer) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	speed := fs.String("speed", "", "Replay Speed Multiplier, or \"instant\" (default - the speed of the game)")
+	step := fs.Bool("step", false, "Pause before every shot")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: tank replay [-speed multiplier] [-step] <recording>")
+	}
+	rec, err := loadRecording(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	multiplier := 0.0
+	if *speed != "" {
+		if multiplier, err = parseSpeedMultiplier(*speed); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(out, "Replaying %s: %d shots, %s\n", fs.Arg(0), len(rec.Shots), rec.Outcome)
+	fmt.Fprintln(out, replayHelp)
+	_, err = replayRecording(context.Background(), rec, multiplier, *step, in, out)
+	return err
+}
+
+// replayRecording plays rec through the battle manager, or the duel, at speedMultiplier times real-time
+// (0 = the speed of the game). The recorded shots are fired at their recorded simulation times, so the
+// replay ends exactly like the game did.
+func replayRecording(ctx context.Context, rec recording, speedMultiplier float64, step bool, in io.Reader, out io.Writer) (*Game, error) {
+	var d *duel
+	var g *Game
+	var err error
+	if rec.Duel {
+		if d, err = newDuel(rec.Config, in, out); d != nil {
+			g = d.g
+		}
+	} else {
+		g, err = NewGame(rec.Config, in, out)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if speedMultiplier == 0.0 {
+		speedMultiplier = g.targetSpeedMultiplier
+	}
+	participants := 1
+	if g.targetModeAuto {
+		participants = 2
+	}
+	clock := newPacedClock(participants, speedMultiplier)
+	g.clock = clock
+	g.replay = &rec
+	g.replayControls = newReplayControls(clock, out)
+	g.replayControls.step = step
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	lines := readLines(in)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case line, ok := <-lines:
+				if !ok {
+					return
+				}
+				if g.replayControls.control(line) {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	if d != nil {
+		d.run(ctx)
+	} else {
+		g.Run(ctx)
+	}
+	return g, nil
+}
+
+// nextReplayShot waits until the next recorded shot is due and returns it. A shot at angle 0 (quit)
+// is returned when the recording runs out or ctx is cancelled.
+func (g *Game) nextReplayShot(ctx context.Context, shotCount int) recordedShot {
+	if shotCount >= len(g.replay.Shots) {
+		if g.replay.Outcome != outcomeQuit {
+			// The game ended after the last shot, wait for the target to end it again.
+			for g.clock.Sleep(ctx, time.Hour) == nil {
+			}
+			return recordedShot{}
+		}
+		g.sleepUntil(ctx, g.replay.EndedAt)
+		return recordedShot{}
+	}
+	shot := g.replay.Shots[shotCount]
+	if g.sleepUntil(ctx, shot.FiredAt) != nil || g.replayControls.beforeShot(ctx, shotCount+1) != nil {
+		return recordedShot{}
+	}
+	return shot
+}
+
+// sleepUntil sleeps until the simulated time t (seconds since the start of the game).
+func (g *Game) sleepUntil(ctx context.Context, t float64) error {
+	d := time.Duration(math.Round(t*float64(time.Second))) - g.clock.Now()
+	if d <= 0 {
+		return ctx.Err()
+	}
+	return g.clock.Sleep(ctx, d)
+}
+
+// replayControls pause, step and change the speed of a replay.
+type replayControls struct {
+	clock *pacedClock
+	out   io.Writer
+	mu    sync.Mutex
+	step  bool // true = pause before the next shot
+}
+
+func newReplayControls(clock *pacedClock, out io.Writer) *replayControls {
+	return &replayControls{clock: clock, out: out}
+}
+
+// beforeShot pauses before shotCount when stepping, and blocks while the replay is paused.
+func (r *replayControls) beforeShot(ctx context.Context, shotCount int) error {
+	r.mu.Lock()
+	if r.step {
+		r.step = false
+		r.clock.update(func(speed *float64, paused *bool) { *paused = true })
+		fmt.Fprintf(r.out, "Paused before shot #%d. %s\n", shotCount, replayHelp)
+	}
+	r.mu.Unlock()
+	for {
+		_, paused, changed := r.clock.state()
+		if !paused {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// control applies a line of replay controls, and reports whether the replay should quit.
+func (r *replayControls) control(line string) (quit bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	line = strings.TrimSpace(line)
+	switch line {
+	case "":
+		r.clock.update(func(speed *float64, paused *bool) {
+			*paused = !*paused
+			if *paused {
+				fmt.Fprintln(r.out, "Paused.", replayHelp)
+			} else {
+				fmt.Fprintln(r.out, "Resumed.")
+			}
+		})
+	case "s":
+		r.step = true
+		r.clock.update(func(speed *float64, paused *bool) { *paused = false })
+	case "+", "-":
+		r.clock.update(func(speed *float64, paused *bool) {
+			if line == "+" {
+				*speed *= 2.0
+			} else {
+				*speed /= 2.0
+			}
+			fmt.Fprintf(r.out, "Replay Speed: %s\n", getSpeedText(*speed))
+		})
+	case "q":
+		return true
+	default:
+		fmt.Fprintln(r.out, replayHelp)
+	}
+	return false
+}
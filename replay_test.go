@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGame_replay(t *testing.T) {
+	type args struct {
+		config      Config
+		input       string
+		targetRange float64
+		targetVmps  float64
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "Auto Shot - Realtime Target",
+			args: args{withDefaults(Config{ShootModeAuto: true, DeathRadius: impactRadius, Speed: "instant", Seed: 3, WindGust: 20.0}), "", 0.0, 0.0},
+		},
+		{
+			name: "Manual Shot - Paused Target",
+			args: args{withDefaults(Config{DeathRadius: impactRadius, Seed: 3}), "10\n20\n0\n", 0.0, 0.0},
+		},
+		{
+			name: "Manual Shot - Realtime Target",
+			args: args{withDefaults(Config{TargetModeAuto: true, DeathRadius: impactRadius, Speed: "1000", Seed: 3}), "10\n20\n0\n", 0.0, 0.0},
+		},
+		{
+			name: "Manual Shot - Realtime Target crushes a waiting player",
+			args: args{withDefaults(Config{TargetModeAuto: true, DeathRadius: impactRadius, Speed: "1000", Seed: 3}), "1\n", 100.0, 50.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The player in the last test never answers the second prompt.
+			var input io.Reader = strings.NewReader(tt.args.input)
+			if tt.args.targetRange > 0.0 {
+				pr, pw := io.Pipe()
+				defer pw.Close()
+				go io.WriteString(pw, tt.args.input)
+				input = pr
+			}
+			g, err := NewGame(tt.args.config, input, io.Discard)
+			if err != nil {
+				t.Fatalf("NewGame() error = %v", err)
+			}
+			if tt.args.targetRange > 0.0 {
+				g.targets[0].distance = tt.args.targetRange
+				g.targets[0].vmps = tt.args.targetVmps
+			}
+			g.Run(context.Background())
+			rec := g.recording()
+
+			replayed, err := replayRecording(context.Background(), rec, math.Inf(1), false, strings.NewReader(""), io.Discard)
+			if err != nil {
+				t.Fatalf("replayRecording() error = %v", err)
+			}
+			if tt.args.targetRange > 0.0 {
+				// The starting values came from the test, not from the seed.
+				return
+			}
+			if got := replayed.result(); got != g.result() {
+				t.Errorf("replayRecording() = %+v, want %+v", got, g.result())
+			}
+			if len(replayed.shots) != len(g.shots) {
+				t.Fatalf("replayRecording() shots = %+v, want %+v", replayed.shots, g.shots)
+			}
+			for i := range g.shots {
+				if replayed.shots[i] != g.shots[i] {
+					t.Errorf("replayRecording() shot #%d = %+v, want %+v", i+1, replayed.shots[i], g.shots[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGame_replay_crushed(t *testing.T) {
+	rec := recording{
+		Version: recordingVersion,
+		Config:  withDefaults(Config{TargetModeAuto: true, DeathRadius: impactRadius, Seed: 3}),
+		Shots:   []recordedShot{{Angle: 1.0, FiredAt: 2.5}},
+		Outcome: outcomeCrushed,
+	}
+	g, err := replayRecording(context.Background(), rec, math.Inf(1), false, strings.NewReader(""), io.Discard)
+	if err != nil {
+		t.Fatalf("replayRecording() error = %v", err)
+	}
+	if got := g.result(); got.Outcome != outcomeCrushed || got.Shots != 1 {
+		t.Errorf("replayRecording() = %+v, want the target to crush the tank after 1 shot", got)
+	}
+	if got, want := g.recordedShots[0].FiredAt, 2.5; got != want {
+		t.Errorf("replayRecording() fired at %v, want %v", got, want)
+	}
+}
+
+func Test_saveRecording(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "game.json")
+
+	rec := recording{
+		Version: recordingVersion,
+		Config:  withDefaults(Config{ShootModeAuto: true, DeathRadius: impactRadius, Seed: 42, Wind: -10.0}),
+		Shots:   []recordedShot{{Angle: 22.5, FiredAt: 0.0}, {Angle: 17.1, FiredAt: 25.773811995}},
+		Outcome: outcomeHit,
+		EndedAt: 45.6,
+	}
+	if err := saveRecording(path, rec); err != nil {
+		t.Fatalf("saveRecording() error = %v", err)
+	}
+	got, err := loadRecording(path)
+	if err != nil {
+		t.Fatalf("loadRecording() error = %v", err)
+	}
+	if got.Config != rec.Config || got.Outcome != rec.Outcome || got.EndedAt != rec.EndedAt || len(got.Shots) != 2 || got.Shots[1] != rec.Shots[1] {
+		t.Errorf("loadRecording() = %+v, want %+v", got, rec)
+	}
+
+	os.WriteFile(path, []byte(`{"version": 99}`), 0644)
+	if _, err := loadRecording(path); err == nil {
+		t.Errorf("loadRecording() of another version, want an error")
+	}
+}
+
+func Test_replayControls_step(t *testing.T) {
+	r := newReplayControls(newPacedClock(1, math.Inf(1)), io.Discard)
+	r.step = true
+	done := make(chan error)
+	go func() {
+		done <- r.beforeShot(context.Background(), 1)
+	}()
+	select {
+	case <-done:
+		t.Fatal("beforeShot() returned while stepping")
+	case <-time.After(50 * time.Millisecond):
+	}
+	r.control("s")
+	if err := <-done; err != nil {
+		t.Errorf("beforeShot() error = %v", err)
+	}
+	if _, paused, _ := r.clock.state(); !r.step || paused {
+		t.Errorf("control(\"s\") = step %v, paused %v, want to pause again before the next shot", r.step, paused)
+	}
+	r.control(" + ")
+	if speed, _, _ := r.clock.state(); !math.IsInf(speed, 1) {
+		t.Errorf("control(\"+\") speed = %v, want %v", speed, math.Inf(1))
+	}
+	if quit := r.control("q"); !quit {
+		t.Errorf("control(\"q\") = %v, want to quit", quit)
+	}
+}
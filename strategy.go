@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const defaultStrategy = "heuristic"
+
+// observation is everything the battle manager knows when it chooses the next shot: the header shown
+// before the shot and the results of the shots so far.
+type observation struct {
+	ProjectileVmps float64       `json:"projectile_vmps"`
+	MaxRange       float64       `json:"max_range"`
+	Target         int           `json:"target"` // the target closest to the tank, numbered from 1
+	TargetRange    float64       `json:"target_range"`
+	TargetVmps     float64       `json:"target_vmps"`
+	TargetBearing  float64       `json:"target_bearing"` // degrees clockwise from north, 0 unless 2D
+	Targets        []targetState `json:"targets"`
+	Wind           float64       `json:"wind_vmps"` // + = tail wind, - = head wind
+	HighAngle      bool          `json:"high_angle"`
+	Time           float64       `json:"time"` // simulated seconds since the start of the game
+	Shots          []shotResult  `json:"shots"`
+
+	ballistics ballistics
+}
+
+// shotResult is what the player learns from a single shot.
+type shotResult struct {
+	Angle       float64 `json:"angle"`
+	Azimuth     float64 `json:"azimuth"` // degrees clockwise from north, 0 unless 2D
+	Range       float64 `json:"range"`
+	FlightTime  float64 `json:"flight_time"`
+	Delta       float64 `json:"delta"`        // + = undershot, - = overshot, along the line of fire
+	Lateral     float64 `json:"lateral"`      // + = the target is right of the line of fire, - = left
+	Target      int     `json:"target"`       // the target nearest to the impact, that Delta is measured against
+	TargetRange float64 `json:"target_range"` // at the time of impact
+	FiredAt     float64 `json:"fired_at"`     // simulated seconds since the start of the game
+}
+
+// strategy chooses the next shot angle in Auto Shot Mode. A strategy keeps its own state, so every
+// game gets a new one.
+type strategy interface {
+	nextAngle(obs observation) float64
+}
+
+var strategies = map[string]func() strategy{
+	"heuristic": func() strategy { return heuristicStrategy{} },
+	"bisect":    func() strategy { return &bisectStrategy{} },
+	"intercept": func() strategy { return interceptStrategy{} },
+	"blind":     func() strategy { return &blindStrategy{} },
+}
+
+func newStrategy(name string) (strategy, error) {
+	if spec := strings.TrimPrefix(name, botPrefix); spec != name {
+		b, err := newBotStrategy(spec)
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	newFunc, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q: want one of %s, or %s<command>", name, getStrategyNames(), botPrefix)
+	}
+	return newFunc(), nil
+}
+
+func getStrategyNames() string {
+	var names []string
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// getFirstShotAngle returns the angle in the middle of the arc that the battle manager shoots.
+func getFirstShotAngle(highAngle bool) float64 {
+	if highAngle {
+		return 90.0 - maxShotAngle/2.0
+	}
+	return maxShotAngle / 2.0
+}
+
+// getArc returns the range of angles for the low or the high arc.
+func getArc(obs observation) (low, high float64) {
+	bestAngle := obs.ballistics.maxRangeAngle(obs.ProjectileVmps)
+	if obs.HighAngle {
+		return bestAngle, maxHighShotAngle
+	}
+	return minShotAngle, bestAngle
+}
+
+// heuristicStrategy corrects the last shot by a little less than the distance the target closes
+// during the flight.
+type heuristicStrategy struct{}
+
+func (heuristicStrategy) nextAngle(obs observation) float64 {
+	if len(obs.Shots) == 0 {
+		return getFirstShotAngle(obs.HighAngle)
+	}
+	last := obs.Shots[len(obs.Shots)-1]
+	shotDelta := last.Delta
+	if last.Target != obs.Target {
+		// The last shot was measured against another target, correct it from where this one is now.
+		shotDelta = obs.TargetRange - last.Range
+	}
+	return predictNextShotAngle(obs, last.Range, last.FlightTime, shotDelta)
+}
+
+func predictNextShotAngle(obs observation, shotRange, shotTime, shotDelta float64) float64 {
+	if obs.HighAngle {
+		// The long flights of the high arc would multiply the error of the fudge factors below, so lead
+		// the target by its full velocity.
+		predictedLocation := shotRange + shotDelta - obs.TargetVmps*shotTime
+		predictedAngle := obs.ballistics.highAngleFor(predictedLocation, obs.ProjectileVmps)
+		bestAngle := obs.ballistics.maxRangeAngle(obs.ProjectileVmps)
+		if math.IsNaN(predictedAngle) {
+			predictedAngle = bestAngle
+		}
+		return math.Max(math.Min(predictedAngle, maxHighShotAngle), bestAngle)
+	}
+	predictedLocation := shotRange + shotDelta - ((obs.TargetVmps * 0.95) * (shotTime * 0.95))
+	if predictedLocation < 0.0 {
+		// The target is predicted to be past the tank, the shortest shot comes closest.
+		return minShotAngle
+	}
+	predictedAngle := obs.ballistics.angleFor(predictedLocation, obs.ProjectileVmps)
+	if math.IsNaN(predictedAngle) {
+		predictedAngle = obs.ballistics.maxRangeAngle(obs.ProjectileVmps)
+	}
+	return math.Max(math.Min(predictedAngle, maxShotAngle), minShotAngle)
+}
+
+// bisectStrategy halves the bracket of angles between the last shots that fell short and long,
+// knowing nothing about the target. The moving target escapes the bracket over time, so every
+// second miss in a row to the same side widens the other side of the bracket again. Every target
+// gets a new bracket.
+type bisectStrategy struct {
+	low, high float64
+	lastShort bool
+	target    int
+	misses    int // shots measured against the target
+}
+
+func (s *bisectStrategy) nextAngle(obs observation) float64 {
+	arcLow, arcHigh := getArc(obs)
+	if len(obs.Shots) == 0 || obs.Target != s.target {
+		s.low, s.high = arcLow, arcHigh
+		s.target = obs.Target
+		s.misses = 0
+	}
+	if len(obs.Shots) == 0 {
+		return getFirstShotAngle(obs.HighAngle)
+	}
+	last := obs.Shots[len(obs.Shots)-1]
+	delta := last.Delta
+	if last.Target != obs.Target {
+		// The last shot was measured against another target, only tell whether it fell short of this one.
+		delta = obs.TargetRange - last.Range
+	}
+	// On the high arc the steeper shots fall shorter.
+	short := (delta > 0.0) != obs.HighAngle
+	again := s.misses > 0 && short == s.lastShort
+	s.lastShort = short
+	s.misses++
+	width := s.high - s.low
+	if short {
+		s.low = last.Angle
+		if again || s.high <= s.low {
+			s.high = math.Min(s.high+width, arcHigh)
+		}
+	} else {
+		s.high = last.Angle
+		if again || s.high <= s.low {
+			s.low = math.Max(s.low-width, arcLow)
+		}
+	}
+	return (s.low + s.high) / 2.0
+}
+
+// interceptStrategy solves for the shot that lands exactly where the target will be, using the
+// known target velocity.
+type interceptStrategy struct{}
+
+func (interceptStrategy) nextAngle(obs observation) float64 {
+	return interceptAngle(obs, obs.TargetVmps)
+}
+
+// interceptAngle returns the angle on the arc whose shot lands on a target at obs.TargetRange that
+// closes at targetVmps during the flight.
+func interceptAngle(obs observation, targetVmps float64) float64 {
+	low, high := getArc(obs)
+	miss := func(angle float64) float64 {
+		x, t := obs.ballistics.flight(angle, obs.ProjectileVmps)
+		return x - (obs.TargetRange - targetVmps*t)
+	}
+	missLow, missHigh := miss(low), miss(high)
+	if (missLow > 0.0) == (missHigh > 0.0) {
+		// The target can't be reached, get as close as possible.
+		if math.Abs(missLow) < math.Abs(missHigh) {
+			return low
+		}
+		return high
+	}
+	for high-low > angleTolerance {
+		mid := (low + high) / 2.0
+		if (miss(mid) > 0.0) == (missLow > 0.0) {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2.0
+}
+
+// blindStrategy intercepts the target like interceptStrategy, but has to estimate the target
+// velocity from the ranges that it observes over time. Every target is watched from scratch.
+type blindStrategy struct {
+	times, ranges []float64
+	target        int
+}
+
+func (s *blindStrategy) nextAngle(obs observation) float64 {
+	if obs.Target != s.target {
+		s.times, s.ranges = nil, nil
+		s.target = obs.Target
+	}
+	if len(obs.Shots) > 0 {
+		if last := obs.Shots[len(obs.Shots)-1]; last.Target == obs.Target {
+			s.observe(last.FiredAt+last.FlightTime, last.TargetRange)
+		}
+	}
+	s.observe(obs.Time, obs.TargetRange)
+	return interceptAngle(obs, s.estimateTargetVmps())
+}
+
+func (s *blindStrategy) observe(time, targetRange float64) {
+	s.times = append(s.times, time)
+	s.ranges = append(s.ranges, targetRange)
+}
+
+// estimateTargetVmps fits a straight line through the observed ranges. The target is assumed to
+// stand still until it has been watched for at least a second.
+func (s *blindStrategy) estimateTargetVmps() float64 {
+	n := float64(len(s.times))
+	var sumT, sumR, sumTT, sumTR float64
+	for i := range s.times {
+		sumT += s.times[i]
+		sumR += s.ranges[i]
+		sumTT += s.times[i] * s.times[i]
+		sumTR += s.times[i] * s.ranges[i]
+	}
+	if s.times[len(s.times)-1]-s.times[0] < 1.0 {
+		return 0.0
+	}
+	slope := (n*sumTR - sumT*sumR) / (n*sumTT - sumT*sumT)
+	return -slope
+}
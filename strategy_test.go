@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func Test_newStrategy(t *testing.T) {
+	for _, name := range strings.Split(getStrategyNames(), ", ") {
+		if _, err := newStrategy(name); err != nil {
+			t.Errorf("newStrategy(%q) error = %v", name, err)
+		}
+	}
+	if _, err := newStrategy("psychic"); err == nil {
+		t.Errorf("newStrategy() of an unknown strategy, want an error")
+	}
+}
+
+func Test_predictNextShotAngle_highAngle(t *testing.T) {
+	obs := observation{HighAngle: true, TargetVmps: 10.0, ProjectileVmps: 400.0}
+	shotRange, shotTime := xRange(60.0, 400.0)
+	got := predictNextShotAngle(obs, shotRange, shotTime, 0.0)
+	want := 90.0 - xAngle(shotRange-10.0*shotTime, 400.0)
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("predictNextShotAngle() = %v, want %v", got, want)
+	}
+	if got := predictNextShotAngle(obs, shotRange, shotTime, 1e6); got != maxShotAngle {
+		t.Errorf("predictNextShotAngle() out of range = %v, want %v", got, maxShotAngle)
+	}
+}
+
+func Test_bisectStrategy(t *testing.T) {
+	tests := []struct {
+		name      string
+		highAngle bool
+		deltas    []float64
+		want      float64
+	}{
+		{
+			name:   "Low Arc - Undershot",
+			deltas: []float64{100.0},
+			want:   (22.5 + 45.0) / 2.0,
+		},
+		{
+			name:   "Low Arc - Undershot then Overshot",
+			deltas: []float64{100.0, -100.0},
+			want:   (22.5 + 33.75) / 2.0,
+		},
+		{
+			name:      "High Arc - Undershot",
+			highAngle: true,
+			deltas:    []float64{100.0},
+			want:      (45.0 + 67.5) / 2.0,
+		},
+		{
+			name:   "Low Arc - Overshot twice reopens the bracket",
+			deltas: []float64{100.0, -100.0, -100.0},
+			want:   (22.5 - 11.25 + 28.125) / 2.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &bisectStrategy{}
+			obs := observation{HighAngle: tt.highAngle, ProjectileVmps: 400.0}
+			angle := s.nextAngle(obs)
+			for _, delta := range tt.deltas {
+				obs.Shots = append(obs.Shots, shotResult{Angle: angle, Delta: delta})
+				angle = s.nextAngle(obs)
+			}
+			if angle != tt.want {
+				t.Errorf("nextAngle() = %v, want %v", angle, tt.want)
+			}
+		})
+	}
+}
+
+func Test_bisectStrategy_newTarget(t *testing.T) {
+	s := &bisectStrategy{}
+	obs := observation{ProjectileVmps: 400.0, Target: 1}
+	angle := s.nextAngle(obs)
+	obs.Shots = append(obs.Shots, shotResult{Angle: angle, Range: 9000.0, Delta: 100.0, Target: 1})
+	angle = s.nextAngle(obs)
+	// Target #1 is gone, and the last shot fell long of target #2.
+	obs.Shots = append(obs.Shots, shotResult{Angle: angle, Range: 11000.0, Delta: 0.0, Target: 1})
+	obs.Target, obs.TargetRange = 2, 5000.0
+	if got, want := s.nextAngle(obs), (minShotAngle+33.75)/2.0; got != want {
+		t.Errorf("nextAngle() = %v, want %v", got, want)
+	}
+}
+
+func Test_interceptAngle(t *testing.T) {
+	drag, _ := newDragModel(defaultDragCoefficient, defaultProjectileMass, defaultCaliber)
+	tests := []struct {
+		name string
+		obs  observation
+	}{
+		{
+			name: "Vacuum - Low Arc",
+			obs:  observation{ProjectileVmps: 400.0, TargetRange: 12000.0, TargetVmps: 15.0},
+		},
+		{
+			name: "Vacuum - High Arc",
+			obs:  observation{ProjectileVmps: 400.0, TargetRange: 12000.0, TargetVmps: 15.0, HighAngle: true},
+		},
+		{
+			name: "Drag in a Head Wind",
+			obs:  observation{ProjectileVmps: 600.0, TargetRange: 8000.0, TargetVmps: 10.0, ballistics: ballistics{drag: drag, wind: -5.0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			angle := interceptAngle(tt.obs, tt.obs.TargetVmps)
+			x, flightTime := tt.obs.ballistics.flight(angle, tt.obs.ProjectileVmps)
+			if miss := x - (tt.obs.TargetRange - tt.obs.TargetVmps*flightTime); math.Abs(miss) > 0.01 {
+				t.Errorf("interceptAngle() = %v, misses by %v", angle, miss)
+			}
+			if tt.obs.HighAngle != (angle > maxShotAngle) {
+				t.Errorf("interceptAngle() = %v, want on the high arc = %v", angle, tt.obs.HighAngle)
+			}
+		})
+	}
+}
+
+func Test_blindStrategy_estimateTargetVmps(t *testing.T) {
+	s := &blindStrategy{}
+	s.observe(0.0, 10000.0)
+	if got := s.estimateTargetVmps(); got != 0.0 {
+		t.Errorf("estimateTargetVmps() before any time passed = %v, want 0", got)
+	}
+	s.observe(40.0, 9400.0)
+	s.observe(40.0, 9400.0)
+	s.observe(75.0, 8875.0)
+	if got := s.estimateTargetVmps(); math.Abs(got-15.0) > 1e-9 {
+		t.Errorf("estimateTargetVmps() = %v, want 15", got)
+	}
+}
+
+func TestGame_Run_strategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		maxShots int
+	}{
+		{"heuristic", 10},
+		{"intercept", 1},
+		{"blind", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			config := withDefaults(Config{ShootModeAuto: true, Strategy: tt.strategy, Seed: 1, Speed: "instant", DeathRadius: impactRadius})
+			g, err := NewGame(config, strings.NewReader(""), io.Discard)
+			if err != nil {
+				t.Fatalf("NewGame() error = %v", err)
+			}
+			g.Run(context.Background())
+			last := g.shots[len(g.shots)-1]
+			if len(g.shots) > tt.maxShots || math.Abs(last.Delta) > impactRadius {
+				t.Errorf("Run() took %d shots and missed the last one by %v, want a hit within %d shots", len(g.shots), last.Delta, tt.maxShots)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+import "os"
+
+// getWindowSize can't ask the terminal for its size here, $LINES and $COLUMNS tell it instead.
+func getWindowSize(f *os.File) (rows, cols int, ok bool) {
+	return 0, 0, false
+}
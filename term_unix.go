@@ -0,0 +1,21 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// getWindowSize asks the terminal of f for its size, ok is false when f isn't a terminal. A terminal
+// that doesn't know its size says 0.
+func getWindowSize(f *os.File) (rows, cols int, ok bool) {
+	var size struct{ rows, cols, xPixels, yPixels uint16 }
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0, 0, false
+	}
+	return int(size.rows), int(size.cols), true
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	defaultTimelineWidth = 50 // characters, when the timeline isn't on a terminal
+	minTimelineWidth     = 20 // characters
+	rulerSection         = 10 // characters between the "+" on the impact path, at least
+)
+
+// setTimelineWidth draws the flight path, the impact path and the ruler of the timeline width
+// characters wide. A wider timeline shows the shot and the targets at a finer resolution.
+func (g *Game) setTimelineWidth(width int) {
+	if width < minTimelineWidth {
+		width = minTimelineWidth
+	}
+	g.width = width
+	g.flightPath = getFlightPath(width)
+	g.impactPath, g.rulerText = g.getRuler(g.maxRange)
+}
+
+// validateTimelineWidth checks the -width flag, 0 = as wide as the terminal.
+func validateTimelineWidth(width int) error {
+	if width != 0 && width < minTimelineWidth {
+		return fmt.Errorf("invalid width %d: want at least %d characters", width, minTimelineWidth)
+	}
+	return nil
+}
+
+// sizeTimelineForStdout sizes the timeline of g for stdout: width characters, or as wide as the terminal
+// when width is 0.
+func sizeTimelineForStdout(g *Game, width int) {
+	if width > 0 {
+		g.setTimelineWidth(width)
+	} else if isTerminal(os.Stdout) {
+		_, cols := getTerminalSize()
+		g.fitTimeline(cols)
+	}
+}
+
+// fitTimeline makes the timeline as wide as fits in cols characters, with the legend of the ruler
+// that sticks out past the end of the impact path.
+func (g *Game) fitTimeline(cols int) {
+	g.setTimelineWidth(cols)
+	if over := len(g.rulerText) - cols; over > 0 {
+		g.setTimelineWidth(cols - over)
+	}
+}
+
+// getFlightPath draws the arc of a shot that lands at the end of an impact path width characters wide.
+func getFlightPath(width int) string {
+	return " /" + strings.Repeat("~", width-2)
+}
+
+// getRuler divides an impact path that ends at maxDistance into sections, as many as leave room for
+// their distance markers, and returns the path with a "+" between the sections and the markers for
+// the legend under it. The decimal point of a marker is under its "+".
+func (g *Game) getRuler(maxDistance float64) (impactPath, ruler string) {
+	sections := g.width / rulerSection
+	for ; sections > 1; sections-- {
+		if impactPath, ruler, ok := g.getRulerSections(maxDistance, sections); ok {
+			return impactPath, ruler
+		}
+	}
+	impactPath, ruler, _ = g.getRulerSections(maxDistance, 1)
+	return impactPath, ruler
+}
+
+// getRulerSections draws the impact path and the ruler with sections, ok is false when the markers
+// don't fit next to each other.
+func (g *Game) getRulerSections(maxDistance float64, sections int) (impactPath, ruler string, ok bool) {
+	path := []byte(strings.Repeat("-", g.width))
+	path[0], path[g.width-1] = '/', '|'
+	var text []byte
+	ok = true
+	for i := 1; i <= sections; i++ {
+		// The same index that getTimelineIndex puts this distance at.
+		tick := i * (g.width - 1) / sections
+		if i < sections {
+			path[tick] = '+'
+		}
+		marker := strings.TrimSpace(g.getRulerText(float64(i) * maxDistance / float64(sections)))
+		start := tick - strings.Index(marker, ".")
+		if len(text) > 0 && start <= len(text) {
+			ok = false
+			start = len(text) + 1
+		}
+		text = append(text, strings.Repeat(" ", start-len(text))...)
+		text = append(text, marker...)
+	}
+	// The unit of the last marker is spelled out.
+	return string(path), string(text[:len(text)-1]) + strings.Title(milesOrKilometers[g.config.EnglishUnits]), ok
+}
+
+func (g *Game) getRulerText(value float64) string {
+	return fmt.Sprintf("%4.1f%1s", getMilesOrKilometers(value, g.config.EnglishUnits), strings.Title(milesOrKilometers[g.config.EnglishUnits][:1]))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGame_setTimelineWidth(t *testing.T) {
+	tests := []struct {
+		name         string
+		englishUnits bool
+		width        int
+		wantImpact   string
+		wantRuler    string
+	}{
+		{
+			name:       "Default",
+			width:      defaultTimelineWidth,
+			wantImpact: "/--------+---------+---------+---------+---------|",
+			wantRuler:  "        2.0K      4.0K      6.0K      8.0K     10.0Kilometers",
+		},
+		{
+			name:       "Narrow",
+			width:      25,
+			wantImpact: "/-----------+-----------|",
+			wantRuler:  "           5.0K       10.0Kilometers",
+		},
+		{
+			name:       "Too narrow",
+			width:      5,
+			wantImpact: "/--------+---------|",
+			wantRuler:  "        5.0K     10.0Kilometers",
+		},
+		{
+			name:       "Wide",
+			width:      80,
+			wantImpact: "/--------+---------+---------+---------+---------+---------+---------+---------|",
+			wantRuler:  "        1.2K      2.5K      3.8K      5.0K      6.2K      7.5K      8.8K     10.0Kilometers",
+		},
+		{
+			name:         "English Units",
+			englishUnits: true,
+			width:        defaultTimelineWidth,
+			wantImpact:   "/--------+---------+---------+---------+---------|",
+			wantRuler:    "        1.2M      2.5M      3.7M      5.0M      6.2Miles",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Game{config: Config{EnglishUnits: tt.englishUnits}, maxRange: 10000.0}
+			g.setTimelineWidth(tt.width)
+			if g.impactPath != tt.wantImpact || g.rulerText != tt.wantRuler {
+				t.Errorf("setTimelineWidth() =\n%s\n%s\nwant\n%s\n%s", g.impactPath, g.rulerText, tt.wantImpact, tt.wantRuler)
+			}
+			if len(g.flightPath) != len(g.impactPath) || !strings.HasPrefix(g.flightPath, " /~") {
+				t.Errorf("setTimelineWidth() flight path = %q, want it as long as the impact path", g.flightPath)
+			}
+		})
+	}
+}
+
+func TestGame_fitTimeline(t *testing.T) {
+	for _, cols := range []int{40, 80, 132, 300} {
+		g := &Game{maxRange: 16000.0}
+		g.fitTimeline(cols)
+		if len(g.rulerText) != cols {
+			t.Errorf("fitTimeline(%d) ruler = %q, want it %d characters long", cols, g.rulerText, cols)
+		}
+		// Every "+" is where getTimelineIndex puts the distance of its marker.
+		sections := strings.Count(g.impactPath, "+") + 1
+		for i := 1; i < sections; i++ {
+			index := getTimelineIndex(float64(i)*g.maxRange/float64(sections), g.maxRange, g.width)
+			if g.impactPath[index-1] != '+' {
+				t.Errorf("fitTimeline(%d) marker %d at %d, want it on a \"+\" of %q", cols, i, index-1, g.impactPath)
+			}
+		}
+	}
+}
+
+func Test_validateTimelineWidth(t *testing.T) {
+	tests := []struct {
+		name    string
+		width   int
+		wantErr bool
+	}{
+		{name: "As wide as the terminal", width: 0},
+		{name: "Narrowest", width: minTimelineWidth},
+		{name: "Wide", width: 500},
+		{name: "Too narrow", width: minTimelineWidth - 1, wantErr: true},
+		{name: "Negative", width: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateTimelineWidth(tt.width); (err != nil) != tt.wantErr {
+				t.Errorf("validateTimelineWidth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
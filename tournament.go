@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// tournamentFormats are the ways that runTournament can print the standings.
+var tournamentFormats = []string{"table", "csv", "json"}
+
+// headToHead counts the games of one strategy against another on the same seeds.
+type headToHead struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Draws  int `json:"draws"`
+}
+
+// standing is the place of a strategy in a tournament.
+type standing struct {
+	Rank       int                   `json:"rank"`
+	Strategy   string                `json:"strategy"`
+	Games      int                   `json:"games"`
+	Wins       int                   `json:"wins"` // games that ended with a hit
+	Crushed    int                   `json:"crushed"`
+	Destroyed  int                   `json:"destroyed"`
+	Failures   int                   `json:"failures"`
+	WinRate    float64               `json:"win_rate"`     // percent
+	MeanShots  *float64              `json:"mean_shots"`   // shots to kill, null without any wins
+	MeanTime   *float64              `json:"mean_time"`    // simulated seconds to kill, null without any wins
+	HeadToHead map[string]headToHead `json:"head_to_head"` // by the other strategy
+
+	results []gameResult
+}
+
+// tournament is the outcome of runTournament, the standings are in the order of their rank.
+type tournament struct {
+	Games     int        `json:"games"` // per strategy
+	FirstSeed int64      `json:"first_seed"`
+	LastSeed  int64      `json:"last_seed"`
+	MaxShots  int        `json:"max_shots"`
+	Standings []standing `json:"standings"`
+}
+
+// runTournament is the tournament command: every strategy named in args, built-in or bot:, plays the
+// same seeded games, and the strategies are ranked by how many they won.
+func runTournament(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("tournament", flag.ExitOnError)
+	configFlags := newConfigFlags(fs)
+	games := fs.Int("games", defaultBatchGames, "Number of Games per Strategy")
+	maxShots := fs.Int("shots", defaultBatchMaxShots, "Shots before a Game counts as a Failure")
+	format := fs.String("format", tournamentFormats[0], "Output Format: "+strings.Join(tournamentFormats, ", "))
+	fs.Parse(args)
+	config, err := configFlags.resolve()
+	if err != nil {
+		return err
+	}
+	config.MaxShots = *maxShots
+
+	names, err := getStrategyArgs(fs)
+	if err != nil {
+		return err
+	}
+	if *games <= 0 {
+		return fmt.Errorf("invalid number of games %d: want at least 1", *games)
+	}
+	var write func(io.Writer, tournament) error
+	switch *format {
+	case "table":
+		write = printTournament
+	case "csv":
+		write = writeTournamentCSV
+	case "json":
+		write = writeTournamentJSON
+	default:
+		return fmt.Errorf("unknown format %q: want one of %s", *format, strings.Join(tournamentFormats, ", "))
+	}
+
+	t, err := playTournament(config, names, *games)
+	if err != nil {
+		return err
+	}
+	return write(out, t)
+}
+
+// playTournament plays games for every strategy like the batch command, and ranks them.
+func playTournament(config Config, names []string, games int) (tournament, error) {
+	t := tournament{Games: games, FirstSeed: config.Seed, LastSeed: config.Seed + int64(games) - 1, MaxShots: config.MaxShots}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if seen[name] {
+			return t, fmt.Errorf("strategy %q is in the tournament twice", name)
+		}
+		seen[name] = true
+		config.Strategy = name
+		stats, err := simulate(config, games)
+		if err != nil {
+			return t, err
+		}
+		t.Standings = append(t.Standings, newStanding(stats))
+	}
+	for i := range t.Standings {
+		for j := range t.Standings {
+			if i != j {
+				t.Standings[i].HeadToHead[t.Standings[j].Strategy] = getHeadToHead(t.Standings[i].results, t.Standings[j].results)
+			}
+		}
+	}
+	// The most wins first, then the fewest shots and the quickest kills.
+	sort.SliceStable(t.Standings, func(a, b int) bool {
+		sa, sb := t.Standings[a], t.Standings[b]
+		if sa.Wins != sb.Wins {
+			return sa.Wins > sb.Wins
+		}
+		if sa.Wins == 0 {
+			return false
+		}
+		if *sa.MeanShots != *sb.MeanShots {
+			return *sa.MeanShots < *sb.MeanShots
+		}
+		return *sa.MeanTime < *sb.MeanTime
+	})
+	for i := range t.Standings {
+		t.Standings[i].Rank = i + 1
+	}
+	return t, nil
+}
+
+func newStanding(stats batchStats) standing {
+	s := standing{
+		Strategy:   stats.Strategy,
+		Games:      stats.Games,
+		Wins:       stats.Hits,
+		Crushed:    stats.Crushed,
+		Destroyed:  stats.Destroyed,
+		Failures:   stats.Failures,
+		WinRate:    100.0 * float64(stats.Hits) / float64(stats.Games),
+		HeadToHead: map[string]headToHead{},
+		results:    stats.Results,
+	}
+	if stats.Hits > 0 {
+		shots, seconds := getDistribution(stats.Shots).Mean, getDistribution(stats.Times).Mean
+		s.MeanShots, s.MeanTime = &shots, &seconds
+	}
+	return s
+}
+
+// getHeadToHead compares the games of two strategies seed by seed. A hit beats a miss, and of two hits
+// the one with fewer shots wins, or the quicker one with as many shots.
+func getHeadToHead(results, others []gameResult) (h headToHead) {
+	for i, r := range results {
+		other := others[i]
+		hit, otherHit := r.Outcome == outcomeHit, other.Outcome == outcomeHit
+		switch {
+		case hit && !otherHit:
+			h.Wins++
+		case !hit && otherHit:
+			h.Losses++
+		case !hit || (r.Shots == other.Shots && r.Time == other.Time):
+			h.Draws++
+		case r.Shots < other.Shots || (r.Shots == other.Shots && r.Time < other.Time):
+			h.Wins++
+		default:
+			h.Losses++
+		}
+	}
+	return h
+}
+
+// getTotalHeadToHead adds up the head-to-head games of s against every other strategy.
+func (s standing) getTotalHeadToHead() (total headToHead) {
+	for _, h := range s.HeadToHead {
+		total.Wins += h.Wins
+		total.Losses += h.Losses
+		total.Draws += h.Draws
+	}
+	return total
+}
+
+func getMeanText(mean *float64, format string) string {
+	if mean == nil {
+		return "---"
+	}
+	return fmt.Sprintf(format, *mean)
+}
+
+// printTournament prints the standings as a table, with the head-to-head results of every pair of
+// strategies below it.
+func printTournament(out io.Writer, t tournament) error {
+	width := len("Strategy")
+	for _, s := range t.Standings {
+		if len(s.Strategy) > width {
+			width = len(s.Strategy)
+		}
+	}
+	fmt.Fprintf(out, "Tournament: %d games per strategy, seeds %d to %d, up to %d shots per game\n", t.Games, t.FirstSeed, t.LastSeed, t.MaxShots)
+	fmt.Fprintln(out, "==================================")
+	fmt.Fprintf(out, "Rank  %-*s  Wins  Win Rate  Mean Shots  Mean Time  Head-to-Head\n", width, "Strategy")
+	for _, s := range t.Standings {
+		h := s.getTotalHeadToHead()
+		fmt.Fprintf(out, "%4d  %-*s  %4d  %7.1f%%  %10s  %9s  %d-%d-%d\n", s.Rank, width, s.Strategy, s.Wins, s.WinRate, getMeanText(s.MeanShots, "%.1f"), getMeanText(s.MeanTime, "%.1fs"), h.Wins, h.Losses, h.Draws)
+	}
+	if len(t.Standings) < 2 {
+		return nil
+	}
+	fmt.Fprintln(out, "==================================")
+	fmt.Fprintln(out, "Head-to-Head: wins-losses-draws of the row against the column")
+	fmt.Fprintf(out, "%4s", "")
+	for _, s := range t.Standings {
+		fmt.Fprintf(out, "  %11s", "#"+strconv.Itoa(s.Rank))
+	}
+	fmt.Fprintln(out, "")
+	for _, s := range t.Standings {
+		fmt.Fprintf(out, "%4s", "#"+strconv.Itoa(s.Rank))
+		for _, other := range t.Standings {
+			cell := "---"
+			if h, ok := s.HeadToHead[other.Strategy]; ok && other.Rank != s.Rank {
+				cell = fmt.Sprintf("%d-%d-%d", h.Wins, h.Losses, h.Draws)
+			}
+			fmt.Fprintf(out, "  %11s", cell)
+		}
+		fmt.Fprintln(out, "")
+	}
+	return nil
+}
+
+// writeTournamentCSV writes a row for every strategy, with a column of head-to-head results for
+// every other strategy.
+func writeTournamentCSV(out io.Writer, t tournament) error {
+	w := csv.NewWriter(out)
+	header := []string{"rank", "strategy", "games", "wins", "crushed", "destroyed", "failures", "win_rate", "mean_shots", "mean_time", "head_to_head_wins", "head_to_head_losses", "head_to_head_draws"}
+	for _, s := range t.Standings {
+		header = append(header, "vs "+s.Strategy)
+	}
+	w.Write(header)
+	mean := func(mean *float64) string {
+		if mean == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*mean, 'f', 3, 64)
+	}
+	for _, s := range t.Standings {
+		h := s.getTotalHeadToHead()
+		row := []string{
+			strconv.Itoa(s.Rank), s.Strategy, strconv.Itoa(s.Games), strconv.Itoa(s.Wins), strconv.Itoa(s.Crushed), strconv.Itoa(s.Destroyed), strconv.Itoa(s.Failures),
+			strconv.FormatFloat(s.WinRate, 'f', 1, 64), mean(s.MeanShots), mean(s.MeanTime),
+			strconv.Itoa(h.Wins), strconv.Itoa(h.Losses), strconv.Itoa(h.Draws),
+		}
+		for _, other := range t.Standings {
+			cell := ""
+			if h, ok := s.HeadToHead[other.Strategy]; ok && other.Rank != s.Rank {
+				cell = fmt.Sprintf("%d-%d-%d", h.Wins, h.Losses, h.Draws)
+			}
+			row = append(row, cell)
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeTournamentJSON(out io.Writer, t tournament) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(t)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_getHeadToHead(t *testing.T) {
+	hit := func(shots int, seconds float64) gameResult {
+		return gameResult{Outcome: outcomeHit, Shots: shots, Time: seconds}
+	}
+	tests := []struct {
+		name    string
+		results []gameResult
+		others  []gameResult
+		want    headToHead
+	}{
+		{
+			name:    "A hit beats a miss",
+			results: []gameResult{hit(5, 100.0), {Outcome: outcomeCrushed}},
+			others:  []gameResult{{Outcome: outcomeOutOfShots}, hit(1, 10.0)},
+			want:    headToHead{Wins: 1, Losses: 1},
+		},
+		{
+			name:    "Fewer shots win",
+			results: []gameResult{hit(2, 100.0), hit(3, 10.0)},
+			others:  []gameResult{hit(3, 10.0), hit(2, 100.0)},
+			want:    headToHead{Wins: 1, Losses: 1},
+		},
+		{
+			name:    "As many shots, the quicker kill wins",
+			results: []gameResult{hit(2, 50.0), hit(2, 60.0)},
+			others:  []gameResult{hit(2, 60.0), hit(2, 50.0)},
+			want:    headToHead{Wins: 1, Losses: 1},
+		},
+		{
+			name:    "Draws",
+			results: []gameResult{hit(2, 50.0), {Outcome: outcomeCrushed}},
+			others:  []gameResult{hit(2, 50.0), {Outcome: outcomeDestroyed}},
+			want:    headToHead{Draws: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getHeadToHead(tt.results, tt.others); got != tt.want {
+				t.Errorf("getHeadToHead() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_playTournament(t *testing.T) {
+	config := withDefaults(Config{DeathRadius: impactRadius, Seed: 1, MaxShots: 5})
+	got, err := playTournament(config, []string{"bisect", "intercept", "heuristic"}, 5)
+	if err != nil {
+		t.Fatalf("playTournament() error = %v", err)
+	}
+	if got.FirstSeed != 1 || got.LastSeed != 5 || len(got.Standings) != 3 {
+		t.Fatalf("playTournament() = %+v, want 3 standings for seeds 1 to 5", got)
+	}
+	first := got.Standings[0]
+	if first.Strategy != "intercept" || first.Rank != 1 || first.Wins != 5 || *first.MeanShots != 1.0 {
+		t.Errorf("playTournament() winner = %+v, want intercept with 5 wins in 1 shot", first)
+	}
+	if h := first.HeadToHead["bisect"]; h.Wins+h.Losses+h.Draws != 5 || h.Losses != 0 {
+		t.Errorf("playTournament() intercept against bisect = %+v, want no losses in 5 games", h)
+	}
+	for i, s := range got.Standings {
+		if s.Rank != i+1 || len(s.HeadToHead) != 2 {
+			t.Errorf("playTournament() standing %d = %+v, want rank %d with 2 head-to-heads", i, s, i+1)
+		}
+	}
+	if _, err := playTournament(config, []string{"intercept", "intercept"}, 5); err == nil {
+		t.Errorf("playTournament() with a strategy twice, want an error")
+	}
+}
+
+func Test_runTournament(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		format  string
+		check   func(out string) error
+		wantErr bool
+	}{
+		{
+			name:   "Table",
+			format: "table",
+			check: func(out string) error {
+				for _, want := range []string{"seeds 7 to 9", "   1  intercept", "Head-to-Head: wins-losses-draws", "3-0-0"} {
+					if !strings.Contains(out, want) {
+						t.Errorf("runTournament() output = %q, want it to contain %q", out, want)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			name:   "CSV",
+			format: "csv",
+			check: func(out string) error {
+				records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+				if err == nil && (len(records) != 3 || records[1][1] != "intercept" || records[0][len(records[0])-1] != "vs blind") {
+					t.Errorf("runTournament() CSV = %q, want a header and the intercept and blind rows", records)
+				}
+				return err
+			},
+		},
+		{
+			name:   "JSON",
+			format: "json",
+			check: func(out string) error {
+				var got tournament
+				err := json.Unmarshal([]byte(out), &got)
+				if err == nil && (got.Games != 3 || len(got.Standings) != 2 || got.Standings[0].HeadToHead["blind"].Wins+got.Standings[0].HeadToHead["blind"].Draws != 3) {
+					t.Errorf("runTournament() JSON = %+v, want 2 standings of 3 games", got)
+				}
+				return err
+			},
+		},
+		{
+			name:    "Unknown Format",
+			format:  "xml",
+			wantErr: true,
+		},
+		{
+			name:    "Strategy Flag",
+			flags:   []string{"-strategy", "heuristic"},
+			format:  "table",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			args := append(tt.flags, "-games", "3", "-seed", "7", "-format", tt.format, "blind", "intercept")
+			err := runTournament(args, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runTournament() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.check != nil {
+				if err := tt.check(out.String()); err != nil {
+					t.Errorf("runTournament() output = %q: %v", out.String(), err)
+				}
+			}
+		})
+	}
+}
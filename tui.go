@@ -0,0 +1,364 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	tuiFrame        = 100 * time.Millisecond // between the frames of the live display
+	defaultTermRows = 24
+	defaultTermCols = 80
+
+	escAltScreen     = "\x1b[?1049h"
+	escMainScreen    = "\x1b[?1049l"
+	escClearScreen   = "\x1b[2J"
+	escClearLine     = "\x1b[K" // to the end of the line
+	escSaveCursor    = "\x1b7"
+	escRestoreCursor = "\x1b8"
+	escHideCursor    = "\x1b[?25l"
+	escShowCursor    = "\x1b[?25h"
+)
+
+func escMoveTo(row, col int) string {
+	return fmt.Sprintf("\x1b[%d;%dH", row, col)
+}
+
+// getTerminalSize returns the size of the terminal on stdout, unless $LINES and $COLUMNS say otherwise,
+// or the size of a classic terminal.
+func getTerminalSize() (rows, cols int) {
+	rows, cols = defaultTermRows, defaultTermCols
+	if r, c, ok := getWindowSize(os.Stdout); ok {
+		if r > 0 {
+			rows = r
+		}
+		if c > 0 {
+			cols = c
+		}
+	}
+	if n, err := strconv.Atoi(os.Getenv("LINES")); err == nil && n > 0 {
+		rows = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		cols = n
+	}
+	return rows, cols
+}
+
+// liveShot is the last shot of the game, in the air or where it landed.
+type liveShot struct {
+	shot       int
+	firedAt    float64 // simulated seconds since the start of the game
+	flightTime float64
+	shotRange  float64
+	azimuth    float64
+	landed     bool
+	hit        bool
+}
+
+// tui is the full-screen display of a game. The header stays at the top and the timeline below it,
+// both redrawn in place as the targets and the shot move, the text of the game scrolls by in a log
+// under them, and the prompt for the next shot stays at the bottom. The game writes its text to the
+// tui, and its events tell the tui where the shot is.
+type tui struct {
+	g          *Game
+	term       io.Writer
+	rows, cols int
+	redraw     chan struct{}
+
+	mu          sync.Mutex // guards the fields below, the game writes to the tui while the frames are drawn
+	log         []string
+	prompt      string
+	placePrompt bool // the prompt is drawn with the next frame, and the cursor left at its end
+	shot        *liveShot
+	incoming    []enemyLanding // the enemy shells that landed since the last shot was fired
+}
+
+func newTUI(term io.Writer) *tui {
+	t := &tui{term: term, redraw: make(chan struct{}, 1)}
+	t.rows, t.cols = getTerminalSize()
+	return t
+}
+
+// attach makes the tui the display of g. The flight of a shot takes its time even when the targets
+// pause, so that it can be watched.
+func (t *tui) attach(g *Game) {
+	t.g = g
+	g.live = true
+	// The clock is there before the game runs, for the first frame.
+	g.clock = g.newClock()
+	if !g.targetModeAuto && !math.IsInf(g.targetSpeedMultiplier, 1) {
+		g.clock = newAnimatedClock(g.clock, g.targetSpeedMultiplier)
+	}
+}
+
+// Write adds the lines of the game to the log. The prompt for the next shot is the only text that is
+// written without a line end.
+func (t *tui) Write(p []byte) (int, error) {
+	text := string(p)
+	t.mu.Lock()
+	if strings.HasSuffix(text, "\n") {
+		t.log = append(t.log, strings.Split(strings.TrimSuffix(text, "\n"), "\n")...)
+	} else {
+		t.prompt, t.placePrompt = text, true
+	}
+	t.mu.Unlock()
+	t.requestRedraw()
+	return len(p), nil
+}
+
+// input returns r, which tells the tui when the player entered a shot.
+func (t *tui) input(r io.Reader) io.Reader {
+	return tuiInput{t, r}
+}
+
+type tuiInput struct {
+	t *tui
+	r io.Reader
+}
+
+func (in tuiInput) Read(p []byte) (int, error) {
+	n, err := in.r.Read(p)
+	if n > 0 {
+		t := in.t
+		t.mu.Lock()
+		// The terminal echoed the input under the prompt, it goes to the log with the prompt instead.
+		for _, line := range strings.Split(strings.TrimSuffix(string(p[:n]), "\n"), "\n") {
+			t.log = append(t.log, t.prompt+line)
+		}
+		t.prompt, t.placePrompt = "", true
+		t.mu.Unlock()
+		t.requestRedraw()
+	}
+	return n, err
+}
+
+// events returns the writer for the event stream of the game, which follows the last shot.
+func (t *tui) events() io.Writer {
+	return tuiEvents{t}
+}
+
+type tuiEvents struct {
+	t *tui
+}
+
+func (e tuiEvents) Write(p []byte) (int, error) {
+	var event struct {
+		Type       string  `json:"type"`
+		Time       float64 `json:"time"`
+		Shot       int     `json:"shot"`
+		Range      float64 `json:"range"`
+		FlightTime float64 `json:"flight_time"`
+		Azimuth    float64 `json:"azimuth"`
+	}
+	if err := json.Unmarshal(p, &event); err != nil {
+		return 0, err
+	}
+	t := e.t
+	t.mu.Lock()
+	switch event.Type {
+	case "shot_fired":
+		t.shot = &liveShot{shot: event.Shot, firedAt: event.Time, flightTime: event.FlightTime, shotRange: event.Range, azimuth: event.Azimuth}
+		t.incoming = nil
+	case "shot_impact":
+		if t.shot != nil {
+			t.shot.landed = true
+		}
+	case "hit":
+		if t.shot != nil && t.shot.shot == event.Shot {
+			t.shot.hit = true
+		}
+	}
+	t.mu.Unlock()
+	t.requestRedraw()
+	return len(p), nil
+}
+
+func (t *tui) requestRedraw() {
+	select {
+	case t.redraw <- struct{}{}:
+	default:
+	}
+}
+
+// play runs the game on the alternate screen, and prints the log on the normal screen once the game is
+// over. An interrupt ends the game like quitting does.
+func (t *tui) play(ctx context.Context) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	fmt.Fprint(t.term, escAltScreen+escClearScreen)
+	if t.g.config.ShootModeAuto {
+		fmt.Fprint(t.term, escHideCursor)
+	}
+	drawCtx, cancel := context.WithCancel(ctx)
+	drawn := make(chan struct{})
+	go func() {
+		defer close(drawn)
+		t.run(drawCtx)
+	}()
+	t.g.Run(ctx)
+	cancel()
+	<-drawn
+
+	fmt.Fprint(t.term, escMainScreen+escShowCursor)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, line := range t.log {
+		fmt.Fprintln(t.term, line)
+	}
+}
+
+// run draws a frame whenever something changed, and at least every tuiFrame, until ctx is done.
+func (t *tui) run(ctx context.Context) {
+	ticker := time.NewTicker(tuiFrame)
+	defer ticker.Stop()
+	for {
+		fmt.Fprint(t.term, t.getFrame())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-t.redraw:
+		}
+	}
+}
+
+// getFrame returns the escape sequences and the text that redraw the screen. The cursor stays where the
+// player is typing, unless the prompt changed.
+func (t *tui) getFrame() string {
+	g := t.g
+	g.mu.Lock()
+	now := g.clock.Now().Seconds()
+	var header strings.Builder
+	g.writeHeader(&header)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	timeline := t.getTimeline(now)
+	g.mu.Unlock()
+
+	lines := []string{fmt.Sprintf("tank - Seed %d - Time %.1f seconds", g.seed, now)}
+	lines = append(lines, strings.Split(strings.TrimSuffix(header.String(), "\n"), "\n")...)
+	lines = append(lines, timeline...)
+	lines = append(lines, "")
+	// The log gets the rows that are left above the prompt, and the row under the prompt stays free for
+	// the line end that the terminal echoes.
+	if logRows := t.rows - 2 - len(lines); logRows > 0 {
+		log := t.log
+		if len(log) > logRows {
+			log = log[len(log)-logRows:]
+		}
+		lines = append(lines, log...)
+		for i := len(log); i < logRows; i++ {
+			lines = append(lines, "")
+		}
+	}
+
+	var frame strings.Builder
+	if !t.placePrompt {
+		frame.WriteString(escSaveCursor)
+	}
+	for i, line := range lines {
+		if i >= t.rows-2 {
+			break
+		}
+		frame.WriteString(escMoveTo(i+1, 1) + t.clip(line) + escClearLine)
+	}
+	if t.placePrompt {
+		prompt := t.clip(t.prompt)
+		frame.WriteString(escMoveTo(t.rows, 1) + escClearLine)
+		frame.WriteString(escMoveTo(t.rows-1, 1) + prompt + escClearLine)
+		t.placePrompt = false
+	} else {
+		frame.WriteString(escRestoreCursor)
+	}
+	return frame.String()
+}
+
+func (t *tui) clip(line string) string {
+	return getVisibleText(line, t.cols)
+}
+
+// getTimeline draws the targets where they are now, the last shot, in the air or where it landed, and
+// the enemy shells that landed since it was fired, on the impact path or the 2D battlefield. The caller
+// holds g.mu and t.mu.
+func (t *tui) getTimeline(now float64) []string {
+	g := t.g
+	// The shells that landed since the last frame are drawn from now on, until the next shot.
+	t.incoming = append(t.incoming, g.incoming...)
+	g.incoming = nil
+	shot := t.shot
+	inFlight := shot != nil && !shot.landed
+	progress := 1.0
+	if inFlight && shot.flightTime > 0.0 {
+		progress = math.Max(0.0, math.Min(1.0, (now-shot.firedAt)/shot.flightTime))
+	}
+	// The targets that pause only move when the shot lands, the tui moves them along meanwhile.
+	getDistance := func(i int) float64 {
+		target := &g.targets[i]
+		if inFlight && !g.targetModeAuto && now > shot.firedAt {
+			return target.distance - target.travel(shot.firedAt, now-shot.firedAt)
+		}
+		return target.distance
+	}
+
+	if g.config.TwoD {
+		m := newBattlefieldMap(g.maxRange)
+		for _, l := range t.incoming {
+			m.mark(math.Abs(l.miss), g.targets[l.target].bearing+getBearingOffset(l.miss), 'x')
+		}
+		if shot != nil {
+			mark := byte('o')
+			if shot.hit {
+				mark = '*'
+			}
+			m.mark(shot.shotRange*progress, shot.azimuth, mark)
+		}
+		for i, target := range g.targets {
+			if !target.destroyed {
+				m.mark(getDistance(i), target.bearing, g.getTargetMarker(i)[0])
+			}
+		}
+		lines := strings.Split(strings.TrimSuffix(m.String(), "\n"), "\n")
+		for i, line := range lines {
+			lines[i] = g.paintTimeline(line)
+		}
+		return lines
+	}
+
+	flight := ""
+	impact := []byte(g.impactPath)
+	for _, l := range t.incoming {
+		impact[getTimelineIndex(math.Abs(l.miss), g.maxRange, g.width)-1] = 'x'
+	}
+	if shot != nil {
+		index := getTimelineIndex(shot.shotRange*progress, g.maxRange, g.width)
+		if inFlight {
+			flight = g.flightPath[:index-2] + "o"
+		} else {
+			flight = g.flightPath[:index-2] + "\\"
+			impact[index-1] = '\\'
+			if shot.hit {
+				impact[index-1] = '*'
+			}
+		}
+	}
+	for i, target := range g.targets {
+		if !target.destroyed {
+			impact[getTimelineIndex(getDistance(i), g.maxRange, g.width)-1] = g.getTargetMarker(i)[0]
+		}
+	}
+	lines := []string{g.paintTimeline(flight), g.paintTimeline(string(impact))}
+	if !g.ballistics.terrain.level() {
+		lines = append(lines, g.getTerrainText())
+	}
+	return append(lines, g.rulerText)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func Test_tui_Write(t *testing.T) {
+	screen := newTUI(&bytes.Buffer{})
+	screen.Write([]byte("Shot #1 went far.\n"))
+	screen.Write([]byte("Enter a shot angle: "))
+	if screen.prompt != "Enter a shot angle: " || !screen.placePrompt {
+		t.Errorf("tui.prompt = %q, want the text without a line end", screen.prompt)
+	}
+	input := screen.input(strings.NewReader("20\n"))
+	input.Read(make([]byte, 10))
+	screen.Write([]byte("\nTwo lines.\n"))
+	want := []string{"Shot #1 went far.", "Enter a shot angle: 20", "", "Two lines."}
+	if strings.Join(screen.log, "|") != strings.Join(want, "|") {
+		t.Errorf("tui.log = %q, want %q", screen.log, want)
+	}
+	if screen.prompt != "" {
+		t.Errorf("tui.prompt after the input = %q, want none", screen.prompt)
+	}
+}
+
+func Test_tui_getTimeline(t *testing.T) {
+	tests := []struct {
+		name       string
+		shot       *liveShot
+		incoming   []enemyLanding
+		now        float64
+		wantFlight string
+		wantImpact string
+	}{
+		{
+			name:       "No shot yet",
+			wantImpact: "/--------+---------+---------+----T----+---------|",
+		},
+		{
+			name:       "Shot in the air, the paused target creeps along",
+			shot:       &liveShot{shot: 1, firedAt: 0.0, flightTime: 20.0, shotRange: 7000.0},
+			now:        10.0,
+			wantFlight: " /~~~~~~~~~~~~~~o",
+			wantImpact: "/--------+---------+---------+---T-----+---------|",
+		},
+		{
+			name:       "Shot landed",
+			shot:       &liveShot{shot: 1, firedAt: 0.0, flightTime: 20.0, shotRange: 4000.0, landed: true},
+			now:        20.0,
+			wantFlight: " /~~~~~~~~~~~~~~~~\\",
+			wantImpact: "/--------+---------\\---------+----T----+---------|",
+		},
+		{
+			name:       "Hit",
+			shot:       &liveShot{shot: 1, firedAt: 0.0, flightTime: 20.0, shotRange: 7000.0, landed: true, hit: true},
+			now:        20.0,
+			wantFlight: " /~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~~\\",
+			wantImpact: "/--------+---------+---------+----*----+---------|",
+		},
+		{
+			name:       "Incoming shells",
+			shot:       &liveShot{shot: 1, firedAt: 0.0, flightTime: 20.0, shotRange: 4000.0, landed: true},
+			incoming:   []enemyLanding{{enemyShell{miss: 2000.0}, 0}, {enemyShell{miss: -6000.0}, 0}},
+			now:        20.0,
+			wantFlight: " /~~~~~~~~~~~~~~~~\\",
+			wantImpact: "/--------x---------\\---------x----T----+---------|",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := withDefaults(Config{DeathRadius: impactRadius, Seed: 1})
+			config.MinProjectileVmps, config.MaxProjectileVmps = 313.0, 313.0 // 10 kilometers
+			config.MinTargetVkph, config.MaxTargetVkph = 36.0, 36.0
+			config.MinStartMeters, config.MaxStartMeters = 7000.0, 7000.0
+			g, err := NewGame(config, strings.NewReader(""), &bytes.Buffer{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			screen := newTUI(&bytes.Buffer{})
+			screen.attach(g)
+			screen.shot = tt.shot
+			g.incoming = tt.incoming
+			if tt.shot != nil && tt.shot.hit {
+				g.targets[0].destroyed = true
+			}
+			got := screen.getTimeline(tt.now)
+			if got[0] != tt.wantFlight || got[1] != tt.wantImpact {
+				t.Errorf("tui.getTimeline() =\n%s\n%s\nwant\n%s\n%s", got[0], got[1], tt.wantFlight, tt.wantImpact)
+			}
+			// The shells stay on the timeline once they are drawn.
+			if again := screen.getTimeline(tt.now); again[1] != got[1] || g.incoming != nil {
+				t.Errorf("tui.getTimeline() again = %q, incoming %v, want %q and the shells taken", again[1], g.incoming, got[1])
+			}
+			if got[len(got)-1] != g.rulerText {
+				t.Errorf("tui.getTimeline() ends with %q, want the ruler", got[len(got)-1])
+			}
+		})
+	}
+}
+
+func Test_tui_events_incoming(t *testing.T) {
+	screen := newTUI(&bytes.Buffer{})
+	screen.incoming = []enemyLanding{{enemyShell{miss: 2000.0}, 0}}
+	fmt.Fprintln(screen.events(), `{"type":"shot_fired","time":30,"shot":2,"range":4000,"flight_time":20}`)
+	if screen.incoming != nil || screen.shot == nil || screen.shot.shot != 2 {
+		t.Errorf("tui.events() incoming = %v, shot = %+v, want the shells cleared by shot 2", screen.incoming, screen.shot)
+	}
+}
+
+func Test_tui_play(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		input    string
+		wantText string
+	}{
+		{
+			name:     "Auto Shot",
+			config:   withDefaults(Config{ShootModeAuto: true, DeathRadius: impactRadius, Seed: 3, Speed: "1000"}),
+			wantText: "Direct hit",
+		},
+		{
+			name:     "Manual Shot, the flight takes its time",
+			config:   withDefaults(Config{DeathRadius: impactRadius, Seed: 3, Speed: "1000"}),
+			input:    "20\n0\n",
+			wantText: "Taking shot #1 at 20.00 degrees",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var term bytes.Buffer
+			screen := newTUI(&term)
+			g, err := NewGame(tt.config, screen.input(strings.NewReader(tt.input)), screen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.streamEvents(screen.events())
+			screen.attach(g)
+			screen.play(context.Background())
+			out := term.String()
+			for _, want := range []string{escAltScreen, "\x1b[1;1Htank - Seed 3", escMainScreen, tt.wantText} {
+				if !strings.Contains(out, want) {
+					t.Errorf("tui.play() output doesn't contain %q", want)
+				}
+			}
+			// The log is printed on the normal screen, without the header.
+			if log := out[strings.LastIndex(out, escMainScreen):]; !strings.Contains(log, tt.wantText) || strings.Contains(log, "Current Target Range") {
+				t.Errorf("tui.play() log = %q, want the text of the game without the header", log)
+			}
+		})
+	}
+}